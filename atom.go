@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Links     []atomLink  `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+func createAtomContent(event ScheduleEvent) string {
+	var sb strings.Builder
+	sb.WriteString("<p>")
+	sb.WriteString(html.EscapeString(event.Slot.ModeName))
+	if event.Slot.Rule != nil {
+		sb.WriteString(" / " + html.EscapeString(event.Slot.Rule.Name))
+	}
+	if event.Slot.Event != nil {
+		sb.WriteString("<br>" + html.EscapeString(event.Slot.Event.Name))
+	}
+	sb.WriteString("</p><ul>")
+	for _, name := range append(append([]string{}, event.Slot.Stages...), event.Slot.Weapons...) {
+		sb.WriteString("<li>" + html.EscapeString(name) + "</li>")
+	}
+	sb.WriteString("</ul>")
+	for _, image := range event.Images {
+		sb.WriteString(fmt.Sprintf(`<img src="%s">`, html.EscapeString(image)))
+	}
+	return sb.String()
+}
+
+// GenerateAtom renders events as described in RFC 4287
+func GenerateAtom(events []ScheduleEvent, selfURL string, timeStamp time.Time) ([]byte, error) {
+	updated := timeStamp
+	if len(events) > 0 {
+		updated = events[0].Published
+	}
+	feed := atomFeed{
+		ID:      "tag:ikabot3,2022:schedule-events",
+		Title:   "ikabot3 schedule events",
+		Updated: updated.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: "ikabot3"},
+		Links:   []atomLink{{Rel: "self", Type: "application/atom+xml", Href: selfURL}},
+	}
+	for _, event := range events {
+		entry := atomEntry{
+			ID:        "tag:ikabot3,2022:" + event.ID,
+			Title:     event.Title,
+			Updated:   event.Published.UTC().Format(time.RFC3339),
+			Published: event.Published.UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "html", Body: createAtomContent(event)},
+		}
+		for idx, image := range event.Images {
+			if idx == 0 {
+				// feed readers open the first stage image
+				entry.Links = append(entry.Links, atomLink{Rel: "alternate", Href: image})
+			}
+			entry.Links = append(entry.Links, atomLink{Rel: "enclosure", Type: "image/png", Href: image})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
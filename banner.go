@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	bannerStageWidth  = 400
+	bannerStageHeight = 225
+	bannerWeaponSize  = 128
+	bannerLabelHeight = 24
+	tableTimeWidth    = 96
+	tableCellWidth    = 320
+	tableCellHeight   = 90
+	// banners of finished slots are never used again
+	bannerRetention = time.Hour * 24 * 7
+)
+
+// BannerRenderer composes a PNG image of a ResponseCard and caches it on disk by slot
+type BannerRenderer struct {
+	sync.Mutex
+	CacheDir string
+	client   *http.Client
+	// images keeps decoded images by URLs since stages and weapons are reused across slots
+	images map[string]image.Image
+}
+
+func NewBannerRenderer(workdir string) *BannerRenderer {
+	return &BannerRenderer{
+		CacheDir: filepath.Join(workdir, "banners"),
+		client:   &http.Client{Timeout: time.Second * 10},
+		images:   map[string]image.Image{},
+	}
+}
+
+func (br *BannerRenderer) cachePath(card ResponseCard) string {
+	return filepath.Join(br.CacheDir, card.Key+".png")
+}
+
+// Render returns a cached banner or composes a new one from images of the card
+func (br *BannerRenderer) Render(card ResponseCard) ([]byte, error) {
+	if card.NotFound || card.Key == "" || len(card.StageImages) == 0 {
+		return nil, errors.New("no images to compose")
+	}
+	br.Lock()
+	defer br.Unlock()
+	if data, err := os.ReadFile(br.cachePath(card)); err == nil {
+		metricsFileCacheTotal.WithLabelValues("banner", "hit").Inc()
+		return data, nil
+	}
+	metricsFileCacheTotal.WithLabelValues("banner", "miss").Inc()
+
+	stages, err := br.fetchImages(card.StageImages)
+	if err != nil {
+		return nil, err
+	}
+	var banner image.Image
+	if len(card.WeaponImages) > 0 {
+		weapons, err := br.fetchImages(card.WeaponImages)
+		if err != nil {
+			return nil, err
+		}
+		banner = composeWeaponGrid(card.Label, card.Color, stages[0], weapons)
+	} else {
+		banner = composeStageBanner(card.Label, card.Color, stages)
+	}
+	var buf bytes.Buffer
+	err = png.Encode(&buf, banner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(br.CacheDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	br.prune()
+	err = os.WriteFile(br.cachePath(card), buf.Bytes(), 0644)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// prune removes banners older than bannerRetention
+func (br *BannerRenderer) prune() {
+	entries, err := os.ReadDir(br.CacheDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < bannerRetention {
+			continue
+		}
+		if err := os.Remove(filepath.Join(br.CacheDir, entry.Name())); err != nil {
+			logger.Sugar().Warnf("Cannot remove banner: %v", err)
+		}
+	}
+}
+
+func (br *BannerRenderer) fetchImages(urls []string) ([]image.Image, error) {
+	var images []image.Image
+	for _, url := range urls {
+		img, err := br.fetchImage(url)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", url, err)
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+func (br *BannerRenderer) fetchImage(url string) (image.Image, error) {
+	if img, found := br.images[url]; found {
+		return img, nil
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", getConfig().UserAgent)
+	resp, err := br.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	img, _, err := image.Decode(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	br.images[url] = img
+	return img, nil
+}
+
+// RenderTable composes a grid of time and modes; cells without images are left blank
+func (br *BannerRenderer) RenderTable(table ScheduleTable) ([]byte, error) {
+	br.Lock()
+	defer br.Unlock()
+	images := map[string]image.Image{}
+	for _, row := range table.Rows {
+		for _, card := range row.Cards {
+			for _, url := range card.StageImages {
+				img, err := br.fetchImage(url)
+				if err != nil {
+					logger.Sugar().Warnf("Cannot fetch %s: %v", url, err)
+					continue
+				}
+				images[url] = img
+			}
+		}
+	}
+	var buf bytes.Buffer
+	err := png.Encode(&buf, composeTableImage(table, images))
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// composeTableImage places rows of the table vertically and modes horizontally
+func composeTableImage(table ScheduleTable, images map[string]image.Image) *image.RGBA {
+	rowHeight := bannerLabelHeight + tableCellHeight
+	grid := image.NewRGBA(image.Rect(0, 0, tableTimeWidth+tableCellWidth*len(table.Modes), bannerLabelHeight+rowHeight*len(table.Rows)))
+	draw.Draw(grid, grid.Bounds(), image.NewUniform(color.RGBA{0x20, 0x20, 0x20, 0xff}), image.Point{}, draw.Src)
+	for column, mode := range table.Modes {
+		header := grid.SubImage(image.Rect(tableTimeWidth+tableCellWidth*column, 0, tableTimeWidth+tableCellWidth*(column+1), bannerLabelHeight)).(*image.RGBA)
+		drawLabelBand(header, mode.getLocalizedModeName(LocaleEN), mode.getColor())
+	}
+	for idx, row := range table.Rows {
+		top := bannerLabelHeight + rowHeight*idx
+		drawText(grid, row.StartTime.Format("01/02 15:04"), image.White, 8, top+rowHeight/2)
+		for column, card := range row.Cards {
+			if card.NotFound {
+				continue
+			}
+			left := tableTimeWidth + tableCellWidth*column
+			label := grid.SubImage(image.Rect(left, top, left+tableCellWidth, top+bannerLabelHeight)).(*image.RGBA)
+			drawLabelBand(label, card.Label, card.Color)
+			if len(card.StageImages) == 0 {
+				continue
+			}
+			imageWidth := tableCellWidth / len(card.StageImages)
+			for n, url := range card.StageImages {
+				img, found := images[url]
+				if !found {
+					continue
+				}
+				dst := image.Rect(left+imageWidth*n, top+bannerLabelHeight, left+imageWidth*(n+1), top+rowHeight)
+				draw.ApproxBiLinear.Scale(grid, dst, img, img.Bounds(), draw.Over, nil)
+			}
+		}
+	}
+	return grid
+}
+
+// composeStageBanner places stages side by side under a label band in the mode color
+func composeStageBanner(label string, modeColor int, stages []image.Image) *image.RGBA {
+	banner := image.NewRGBA(image.Rect(0, 0, bannerStageWidth*len(stages), bannerLabelHeight+bannerStageHeight))
+	drawLabelBand(banner, label, modeColor)
+	for idx, stage := range stages {
+		dst := image.Rect(bannerStageWidth*idx, bannerLabelHeight, bannerStageWidth*(idx+1), bannerLabelHeight+bannerStageHeight)
+		draw.ApproxBiLinear.Scale(banner, dst, stage, stage.Bounds(), draw.Over, nil)
+	}
+	return banner
+}
+
+// composeWeaponGrid places the stage and weapons in a 2x2 grid next to it
+func composeWeaponGrid(label string, modeColor int, stage image.Image, weapons []image.Image) *image.RGBA {
+	gridHeight := bannerWeaponSize * 2
+	stageWidth := gridHeight * 16 / 9
+	banner := image.NewRGBA(image.Rect(0, 0, stageWidth+bannerWeaponSize*2, bannerLabelHeight+gridHeight))
+	drawLabelBand(banner, label, modeColor)
+	draw.Draw(banner, image.Rect(stageWidth, bannerLabelHeight, banner.Bounds().Dx(), banner.Bounds().Dy()), image.NewUniform(color.RGBA{0x20, 0x20, 0x20, 0xff}), image.Point{}, draw.Src)
+	draw.ApproxBiLinear.Scale(banner, image.Rect(0, bannerLabelHeight, stageWidth, bannerLabelHeight+gridHeight), stage, stage.Bounds(), draw.Over, nil)
+	for idx, weapon := range weapons {
+		if idx >= 4 {
+			break
+		}
+		x := stageWidth + bannerWeaponSize*(idx%2)
+		y := bannerLabelHeight + bannerWeaponSize*(idx/2)
+		draw.ApproxBiLinear.Scale(banner, image.Rect(x, y, x+bannerWeaponSize, y+bannerWeaponSize), weapon, weapon.Bounds(), draw.Over, nil)
+	}
+	return banner
+}
+
+// drawLabelBand fills the top of the image with the mode color and the label
+func drawLabelBand(banner *image.RGBA, label string, modeColor int) {
+	bounds := banner.Bounds()
+	band := color.RGBA{uint8(modeColor >> 16), uint8(modeColor >> 8), uint8(modeColor), 0xff}
+	draw.Draw(banner, image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Min.Y+bannerLabelHeight), image.NewUniform(band), image.Point{}, draw.Src)
+	drawText(banner, label, image.Black, bounds.Min.X+8, bounds.Min.Y+bannerLabelHeight/2)
+}
+
+// drawText draws ASCII text vertically centered at y
+func drawText(dst *image.RGBA, text string, src image.Image, x int, y int) {
+	drawer := font.Drawer{
+		Dst:  dst,
+		Src:  src,
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y+basicfont.Face7x13.Ascent/2),
+	}
+	drawer.DrawString(text)
+}
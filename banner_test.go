@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestImage(width int, height int, c color.RGBA) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestComposeBanners(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	tests := []struct {
+		name   string
+		banner *image.RGBA
+		size   image.Point
+		// pixels must have the colors
+		pixels map[image.Point]color.RGBA
+	}{
+		{
+			name:   "stages must be placed side by side",
+			banner: composeStageBanner("Splat Zones", 0x74f1a2, []image.Image{newTestImage(16, 9, red), newTestImage(32, 18, blue)}),
+			size:   image.Pt(bannerStageWidth*2, bannerLabelHeight+bannerStageHeight),
+			pixels: map[image.Point]color.RGBA{
+				{bannerStageWidth*2 - 1, 0}:                     {0x74, 0xf1, 0xa2, 0xff},
+				{10, bannerLabelHeight + 10}:                    red,
+				{bannerStageWidth + 10, bannerLabelHeight + 10}: blue,
+			},
+		},
+		{
+			name:   "weapons must be placed in a grid next to the stage",
+			banner: composeWeaponGrid("Salmon Run", 0xff501e, newTestImage(16, 9, red), []image.Image{newTestImage(8, 8, red), newTestImage(8, 8, blue), newTestImage(8, 8, red), newTestImage(8, 8, blue)}),
+			size:   image.Pt(bannerWeaponSize*2*16/9+bannerWeaponSize*2, bannerLabelHeight+bannerWeaponSize*2),
+			pixels: map[image.Point]color.RGBA{
+				{10, bannerLabelHeight + 10}: red,
+				{bannerWeaponSize*2*16/9 + bannerWeaponSize + 10, bannerLabelHeight + 10}:                    blue,
+				{bannerWeaponSize*2*16/9 + bannerWeaponSize + 10, bannerLabelHeight + bannerWeaponSize + 10}: blue,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.banner.Bounds().Size() != tt.size {
+				t.Errorf("size = %v, want %v", tt.banner.Bounds().Size(), tt.size)
+			}
+			for point, want := range tt.pixels {
+				if got := tt.banner.RGBAAt(point.X, point.Y); got != want {
+					t.Errorf("pixel at %v = %v, want %v", point, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBannerRenderer_Render(t *testing.T) {
+	var lock sync.Mutex
+	requested := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requested += 1
+		lock.Unlock()
+		if r.URL.Path == "/missing.png" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		png.Encode(w, newTestImage(16, 9, color.RGBA{0xff, 0, 0, 0xff}))
+	}))
+	defer server.Close()
+
+	br := NewBannerRenderer(t.TempDir())
+	card := ResponseCard{
+		Key:         "X-20221024T000000Z",
+		Label:       "Splat Zones",
+		Color:       0x74f1a2,
+		StartTime:   time.Now(),
+		StageImages: []string{server.URL + "/1.png", server.URL + "/2.png"},
+	}
+	first, err := br.Render(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(bytes.NewReader(first)); err != nil {
+		t.Errorf("banner must be a PNG: %v", err)
+	}
+
+	// banners of the same slot must be read from the cache
+	second, err := br.Render(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) || requested != 2 {
+		t.Errorf("banner is composed again; requested = %d", requested)
+	}
+
+	card.Key = "X-20221024T020000Z"
+	card.StageImages = []string{server.URL + "/missing.png"}
+	if _, err := br.Render(card); err == nil {
+		t.Errorf("missing images must be an error")
+	}
+	if _, err := br.Render(ResponseCard{NotFound: true}); err == nil {
+		t.Errorf("cards without slots must be an error")
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Searcher is implemented by ScheduleStore
+type Searcher interface {
+	MaybeRefresh()
+	Search(query *SearchQuery) SearchResult
+	// StageNames lists stages in cached schedules for completion
+	StageNames() []string
+	Snapshot() (*AllScheduleInfo, *[]TimeSlotInfo)
+	Events() []ScheduleEvent
+	// Now is the time which searches are based on
+	Now() time.Time
+	Sources() []DataSource
+}
+
+// BotRequest is an incoming request from any chat platform
+type BotRequest struct {
+	// Text is a keyword input; ignored if Command is given
+	Text string
+	// Command is a name of structured command such as slash commands
+	Command string
+	Options map[string]string
+	// Mentioned is true if the bot is explicitly called
+	Mentioned bool
+	// Transport is a name of the chat platform for metrics
+	Transport string
+	// DefaultMode is applied to keywords without modes such as 次の
+	DefaultMode string
+	// Locale of replies; empty means the language of keywords
+	Locale string
+}
+
+type BotResponse struct {
+	// Ignored is true if the bot should not reply to the request
+	Ignored bool
+	// Text is set instead of Cards for error messages
+	Text   string
+	Cards  []ResponseCard
+	Result *SearchResult
+	// Compact asks transports to render Cards in a short form such as the overview
+	Compact bool
+	// Table is given by /table; Text has the table in monospaced text
+	Table *ScheduleTable
+	// Explanation is given by /explain and ?debug; Text has it in a code block
+	Explanation *Explanation
+}
+
+// Bot is a transport-neutral core shared by chat adapters
+type Bot struct {
+	Store Searcher
+	// PublicURL is a base URL of HTTPServer to tell feeds to users
+	PublicURL string
+}
+
+func NewBot(store Searcher) *Bot {
+	return &Bot{Store: store}
+}
+
+var commandName2mode = map[string]string{
+	"regular":   "REGULAR",
+	"bankara":   "BANKARA",
+	"open":      "OPEN",
+	"challenge": "CHALLENGE",
+	"salmon":    "SALMON",
+	"x":         "X",
+}
+
+func (b *Bot) buildCommandQuery(command string, options map[string]string) *SearchQuery {
+	if modeName, found := commandName2mode[command]; found {
+		return &SearchQuery{Mode: getMode(modeName)}
+	}
+	switch command {
+	case "now":
+		return &SearchQuery{Mode: getMode("ALL")}
+	case "next":
+		return &SearchQuery{Mode: getMode("ALL"), RelativeIndex: "1"}
+	case "rule":
+		if rule, found := options["rule"]; found {
+			return &SearchQuery{Mode: getMode("BYRULE"), Rule: rule}
+		}
+	}
+	return nil
+}
+
+// buildCommandQueries allows /ika to have multiple queries as keywords do
+func (b *Bot) buildCommandQueries(command string, options map[string]string) ([]*SearchQuery, error) {
+	if command != "ika" {
+		if query := b.buildCommandQuery(command, options); query != nil {
+			return []*SearchQuery{query}, nil
+		}
+		return nil, nil
+	}
+	if text, found := options["query"]; found {
+		queries, _, err := parseKeywords(text, true)
+		// nothing matched; treat as an invalid command
+		if queries[0].OriginalText != "" {
+			return queries, nil
+		}
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *Bot) handleCalendar(options map[string]string, locale string) BotResponse {
+	if b.PublicURL == "" {
+		return BotResponse{Text: localize(locale, "calendar_disabled")}
+	}
+	params := url.Values{}
+	for _, key := range []string{"mode", "rule", "stage", "bigrun"} {
+		if value, found := options[key]; found {
+			params.Set(key, value)
+		}
+	}
+	filter, err := ParseCalendarFilter(params)
+	var unknown *UnknownNameError
+	if errors.As(err, &unknown) {
+		if unknown.Suggestion != "" {
+			return BotResponse{Text: localize(locale, "did_you_mean", unknown.Suggestion)}
+		}
+		return BotResponse{Text: localize(locale, "unknown_name", unknown.Input)}
+	}
+	return BotResponse{Text: b.CalendarURL(filter)}
+}
+
+func (b *Bot) CalendarURL(filter CalendarFilter) string {
+	u := strings.TrimSuffix(b.PublicURL, "/") + "/v1/calendar.ics"
+	if params := filter.Values(); len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+func queryModeLabel(query *SearchQuery) string {
+	if query == nil || query.Mode == nil {
+		return ""
+	}
+	return query.Mode.getIdentifier()
+}
+
+// parseKeywords tries English keywords first since the Japanese grammar matches a part of any text;
+// English words and loosely spelled keywords are common in chats and parsed only if the bot is explicitly called.
+// the first query has empty OriginalText if nothing matched; normalized clauses are nil for English
+func parseKeywords(text string, mentioned bool) ([]*SearchQuery, []string, error) {
+	if !mentioned {
+		return parseAll(NormalizeInput(text), false)
+	}
+	if queries := ParseAllEnglish(text); queries != nil {
+		return queries, nil, nil
+	}
+	return parseAll(NormalizeInput(text), true)
+}
+
+func (b *Bot) Handle(req BotRequest) BotResponse {
+	if req.Command != "" {
+		metricsRequestsTotal.WithLabelValues(req.Transport, "command").Inc()
+	} else {
+		metricsRequestsTotal.WithLabelValues(req.Transport, "text").Inc()
+	}
+	if req.Command == "calendar" {
+		return b.handleCalendar(req.Options, req.Locale)
+	}
+	if req.Command == "table" {
+		return b.handleTable(req.Options, req.Locale)
+	}
+	if req.Command == "explain" {
+		return b.handleExplain(req.Options["query"], req.DefaultMode)
+	}
+	// diagnostics are replied only if the bot is explicitly called not to reply to chats
+	if text, found := trimDebugFlag(req.Text); req.Command == "" && req.Mentioned && found {
+		return b.handleExplain(text, req.DefaultMode)
+	}
+
+	var queries []*SearchQuery
+	if req.Command != "" {
+		var err error
+		queries, err = b.buildCommandQueries(req.Command, req.Options)
+		if queries == nil {
+			if req.Command == "ika" {
+				metricsParseTotal.WithLabelValues("", "failure").Inc()
+			}
+			if errors.Is(err, errTooManyQueries) {
+				return BotResponse{Text: localize(req.Locale, "too_many_queries", maxQueryClauses)}
+			}
+			if suggestion := suggestKeyword(NormalizeInput(req.Options["query"])); req.Command == "ika" && suggestion != "" {
+				return BotResponse{Text: localize(req.Locale, "did_you_mean", suggestion)}
+			}
+			return BotResponse{Text: localize(req.Locale, "invalid_command")}
+		}
+	} else {
+		var err error
+		queries, _, err = parseKeywords(req.Text, req.Mentioned)
+		// ignore when no match
+		if queries[0].OriginalText == "" {
+			// unrelated chats are not failures; suggest only if the bot is explicitly called
+			if !req.Mentioned {
+				return BotResponse{Ignored: true}
+			}
+			metricsParseTotal.WithLabelValues("", "failure").Inc()
+			if errors.Is(err, errTooManyQueries) {
+				return BotResponse{Text: localize(req.Locale, "too_many_queries", maxQueryClauses)}
+			}
+			if suggestion := suggestKeyword(NormalizeInput(req.Text)); suggestion != "" {
+				return BotResponse{Text: localize(req.Locale, "did_you_mean", suggestion)}
+			}
+			return BotResponse{Ignored: true}
+		}
+		for _, query := range queries {
+			metricsParseTotal.WithLabelValues(queryModeLabel(query), "success").Inc()
+		}
+		applyDefaultMode(queries, req.DefaultMode)
+	}
+
+	locale := req.Locale
+	if locale == "" {
+		locale = queries[0].Language
+	}
+	sr, cards := b.searchQueries(queries, locale)
+	if sr.Found {
+		return BotResponse{Cards: cards, Result: &sr, Compact: queries[0].Mode.getIdentifier() == "ALL"}
+	}
+	// reply Not Found only if the bot is explicitly called
+	if req.Command != "" || req.Mentioned {
+		return BotResponse{Text: localize(locale, "not_found"), Result: &sr}
+	}
+	return BotResponse{Ignored: true, Result: &sr}
+}
+
+// applyDefaultMode gives the default mode of the guild to keywords without modes such as 次の and 19時の
+func applyDefaultMode(queries []*SearchQuery, defaultMode string) {
+	for _, query := range queries {
+		if query.Mode.getIdentifier() == "" && query.Rule == "" && (query.RelativeIndex != "" || query.TimeIndex != "") && defaultMode != "" {
+			query.Mode = getMode(defaultMode)
+		}
+	}
+}
+
+// handleExplain searches keywords as Handle does but replies how they are parsed and searched
+func (b *Bot) handleExplain(text string, defaultMode string) BotResponse {
+	queries, normalized, err := parseKeywords(text, true)
+	explanation := Explanation{Input: text, Parser: "japanese", Normalized: normalized}
+	if err != nil {
+		explanation.Error = err.Error()
+	}
+	if queries[0].Language == LocaleEN {
+		explanation.Parser = "english"
+	}
+	if queries[0].OriginalText != "" {
+		applyDefaultMode(queries, defaultMode)
+		for _, query := range queries {
+			explanation.Results = append(explanation.Results, b.Search(query))
+		}
+	} else if err == nil {
+		explanation.Suggestion = suggestKeyword(NormalizeInput(text))
+	}
+	explanation.Sources = b.Store.Sources()
+	explanation.Now = b.Store.Now()
+	return BotResponse{Text: "```\n" + explanation.Text() + "\n```", Explanation: &explanation}
+}
+
+// searchQueries merges results of queries into one; queries without results are skipped
+// and slots found by several queries such as ガチマとエリア are shown once
+func (b *Bot) searchQueries(queries []*SearchQuery, locale string) (SearchResult, []ResponseCard) {
+	merged := SearchResult{Query: queries[0]}
+	var cards []ResponseCard
+	seen := map[string]bool{}
+	for _, query := range queries {
+		sr := b.Search(query)
+		merged.TimeStamp = sr.TimeStamp
+		if !sr.Found {
+			continue
+		}
+		merged.Found = true
+		for idx, card := range createResponseCards(sr, locale) {
+			key := card.Key
+			if card.NotFound {
+				key = "NOTFOUND-" + card.ModeName
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged.Slots = append(merged.Slots, sr.Slots[idx])
+			cards = append(cards, card)
+		}
+	}
+	return merged, cards
+}
+
+// tableTextLength fits the table in a Discord message with a code block
+const tableTextLength = 1900
+
+func (b *Bot) handleTable(options map[string]string, locale string) BotResponse {
+	mode := strings.ToUpper(options["mode"])
+	if mode == "" {
+		mode = "ALL"
+	}
+	identifiers, found := tableModes[mode]
+	if !found {
+		return BotResponse{Text: localize(locale, "invalid_command")}
+	}
+	b.Store.MaybeRefresh()
+	info, salmonInfo := b.Store.Snapshot()
+	table := buildScheduleTable(info, salmonInfo, identifiers, b.Store.Now(), locale)
+	if len(table.Rows) == 0 {
+		return BotResponse{Text: localize(locale, "not_found")}
+	}
+	return BotResponse{Text: "```\n" + table.Text(tableTextLength) + "\n```", Table: &table}
+}
+
+// Search queries to the schedule store with refreshing outdated caches
+func (b *Bot) Search(query *SearchQuery) SearchResult {
+	b.Store.MaybeRefresh()
+	sr := b.Store.Search(query)
+	if sr.Found {
+		metricsSearchTotal.WithLabelValues(queryModeLabel(query), "found").Inc()
+	} else {
+		metricsSearchTotal.WithLabelValues(queryModeLabel(query), "not_found").Inc()
+	}
+	return sr
+}
+
+// Complete returns candidates for the option of structured commands
+func (b *Bot) Complete(option string, input string) []DictionaryEntry {
+	var dictionary []DictionaryEntry
+	switch option {
+	case "stage":
+		dictionary = mergeDictionary(StageDictionary, b.Store.StageNames())
+	case "rule":
+		dictionary = RuleDictionary
+	}
+	// Discord accepts at most 25 choices
+	return completeDictionary(dictionary, input, 25)
+}
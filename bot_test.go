@@ -0,0 +1,441 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testJST = time.FixedZone("JST", 9*60*60)
+
+// testStore serves fixed schedules as of testNow without fetching
+type testStore struct {
+	*ScheduleStore
+	now time.Time
+}
+
+var testNow = time.Date(2023, 3, 2, 12, 30, 0, 0, testJST)
+
+func (ts *testStore) MaybeRefresh() {}
+
+func (ts *testStore) Search(query *SearchQuery) SearchResult {
+	return searchAll(query, ts.info, ts.salmonInfo, ts.now)
+}
+
+func (ts *testStore) Now() time.Time {
+	return ts.now
+}
+
+func (ts *testStore) Sources() []DataSource {
+	return []DataSource{
+		{Name: "schedule", URL: "https://example.com/api/schedule", UpdatedAt: ts.now.Add(-time.Minute * 5)},
+		{Name: "salmon", URL: "https://example.com/api/coop-grouping-regular/schedule", UpdatedAt: ts.now.Add(-time.Hour)},
+	}
+}
+
+func newTestTimeSlots(rules []RuleInfo, stages [][2]string) []TimeSlotInfo {
+	var slots []TimeSlotInfo
+	start := time.Date(2023, 3, 2, 11, 0, 0, 0, testJST)
+	for idx := range stages {
+		slots = append(slots, TimeSlotInfo{
+			StartTime: start.Add(time.Hour * time.Duration(2*idx)),
+			EndTime:   start.Add(time.Hour * time.Duration(2*idx+2)),
+			Rule:      rules[idx%len(rules)],
+			Stages: []StageInfo{
+				{Name: stages[idx][0]},
+				{Name: stages[idx][1]},
+			},
+		})
+	}
+	return slots
+}
+
+func newTestStore() *testStore {
+	turfWar := RuleInfo{Key: "TURF_WAR", Name: "ナワバリバトル"}
+	area := RuleInfo{Key: "AREA", Name: "ガチエリア"}
+	loft := RuleInfo{Key: "LOFT", Name: "ガチヤグラ"}
+	goal := RuleInfo{Key: "GOAL", Name: "ガチホコバトル"}
+	clam := RuleInfo{Key: "CLAM", Name: "ガチアサリ"}
+	stages := [][2]string{
+		{"ユノハナ大渓谷", "ゴンズイ地区"},
+		{"ヤガラ市場", "マテガイ放水路"},
+		{"ナメロウ金属", "クサヤ温泉"},
+		{"ヒラメが丘団地", "マサバ海峡大橋"},
+		{"キンメダイ美術館", "マヒマヒリゾート＆スパ"},
+		{"海女美術大学", "チョウザメ造船"},
+	}
+	info := &AllScheduleInfo{
+		Regular:          newTestTimeSlots([]RuleInfo{turfWar}, stages),
+		BankaraChallenge: newTestTimeSlots([]RuleInfo{area, loft, goal, clam}, stages[1:]),
+		BankaraOpen:      newTestTimeSlots([]RuleInfo{loft, goal, clam, area}, stages[2:]),
+		XMatch:           newTestTimeSlots([]RuleInfo{goal, clam, area, loft}, stages[3:]),
+	}
+	salmonStart := time.Date(2023, 3, 1, 17, 0, 0, 0, testJST)
+	salmonInfo := &[]TimeSlotInfo{
+		{
+			StartTime: salmonStart,
+			EndTime:   salmonStart.Add(time.Hour * 40),
+			Stage:     StageInfo{Name: "シェケナダム"},
+			Weapons:   []WeaponInfo{{Name: "スプラシューター"}, {Name: "パブロ"}, {Name: "リッター4K"}, {Name: "ヒッセン"}},
+		},
+		{
+			StartTime: salmonStart.Add(time.Hour * 40),
+			EndTime:   salmonStart.Add(time.Hour * 80),
+			Stage:     StageInfo{Name: "アラマキ砦"},
+			Weapons:   []WeaponInfo{{Name: "ランダム"}, {Name: "ランダム"}, {Name: "ランダム"}, {Name: "ランダム"}},
+		},
+		{
+			StartTime: salmonStart.Add(time.Hour * 80),
+			EndTime:   salmonStart.Add(time.Hour * 120),
+			Stage:     StageInfo{Name: "スメーシーワールド"},
+			Weapons:   []WeaponInfo{{Name: "わかばシューター"}, {Name: "ホクサイ"}, {Name: "スプラチャージャー"}, {Name: "バケットスロッシャー"}},
+			IsBigRun:  true,
+		},
+	}
+	return &testStore{
+		ScheduleStore: &ScheduleStore{info: info, salmonInfo: salmonInfo},
+		now:           testNow,
+	}
+}
+
+func TestBot_Handle(t *testing.T) {
+	type cardSummary struct {
+		ModeName string
+		Title    string
+		Lines    []string
+		NotFound bool
+	}
+	tests := []struct {
+		name        string
+		req         BotRequest
+		wantIgnored bool
+		wantText    string
+		wantCards   []cardSummary
+	}{
+		{
+			name: "ガチマ must return the current Challenge slot",
+			req:  BotRequest{Text: "ガチマ"},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチエリア", Lines: []string{"ヤガラ市場", "マテガイ放水路"}},
+			},
+		},
+		{
+			name: "次のガチマ with mention syntax must return the next Challenge slot",
+			req:  BotRequest{Text: "<@1018084105587544166> 次のガチマ", Mentioned: true},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチヤグラ", Lines: []string{"ナメロウ金属", "クサヤ温泉"}},
+			},
+		},
+		{
+			name: "15 時のバンカラ must return both Challenge and Open slots",
+			req:  BotRequest{Text: "15 時のバンカラ"},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチホコバトル", Lines: []string{"ヒラメが丘団地", "マサバ海峡大橋"}},
+				{ModeName: "バンカラマッチ（オープン）", Title: "ガチアサリ", Lines: []string{"キンメダイ美術館", "マヒマヒリゾート＆スパ"}},
+			},
+		},
+		{
+			name: "午後3時のバンカラ must return the same slots as 15 時",
+			req:  BotRequest{Text: "午後3時のバンカラ"},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチホコバトル", Lines: []string{"ヒラメが丘団地", "マサバ海峡大橋"}},
+				{ModeName: "バンカラマッチ（オープン）", Title: "ガチアサリ", Lines: []string{"キンメダイ美術館", "マヒマヒリゾート＆スパ"}},
+			},
+		},
+		{
+			name: "15:30 のバンカラ must return the slots from 15 時",
+			req:  BotRequest{Text: "15:30 のバンカラ"},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチホコバトル", Lines: []string{"ヒラメが丘団地", "マサバ海峡大橋"}},
+				{ModeName: "バンカラマッチ（オープン）", Title: "ガチアサリ", Lines: []string{"キンメダイ美術館", "マヒマヒリゾート＆スパ"}},
+			},
+		},
+		{
+			name: "次のガチマと次のシャケ must return both slots",
+			req:  BotRequest{Text: "次のガチマと次のシャケ"},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチヤグラ", Lines: []string{"ナメロウ金属", "クサヤ温泉"}},
+				{ModeName: "サーモンラン", Title: "アラマキ砦", Lines: []string{"ランダム", "ランダム", "ランダム", "ランダム"}},
+			},
+		},
+		{
+			name: "slots found by several clauses must be shown once",
+			req:  BotRequest{Text: "ガチマとエリア"},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチエリア", Lines: []string{"ヤガラ市場", "マテガイ放水路"}},
+				{ModeName: "バンカラマッチ（オープン）", Title: "ガチエリア", Lines: []string{"海女美術大学", "チョウザメ造船"}},
+				{ModeName: "Xマッチ", Title: "ガチエリア", Lines: []string{"海女美術大学", "チョウザメ造船"}},
+			},
+		},
+		{
+			name: "次のシャケ must return the next Salmon Run slot",
+			req:  BotRequest{Text: "次のシャケ"},
+			wantCards: []cardSummary{
+				{ModeName: "サーモンラン", Title: "アラマキ砦", Lines: []string{"ランダム", "ランダム", "ランダム", "ランダム"}},
+			},
+		},
+		{
+			name: "次の with the default mode of the guild must return the next slot of the mode",
+			req:  BotRequest{Text: "次の", DefaultMode: "CHALLENGE"},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチヤグラ", Lines: []string{"ナメロウ金属", "クサヤ温泉"}},
+			},
+		},
+		{
+			name: "19時の with the default mode of the guild must return the slot of the mode",
+			req:  BotRequest{Text: "13時の", DefaultMode: "CHALLENGE"},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチヤグラ", Lines: []string{"ナメロウ金属", "クサヤ温泉"}},
+			},
+		},
+		{
+			name: "English keywords must be replied in English",
+			req:  BotRequest{Text: "next anarchy series", Mentioned: true},
+			wantCards: []cardSummary{
+				{ModeName: "Anarchy Battle (Series)", Title: "Tower Control", Lines: []string{"Mincemeat Metalworks", "Brinewater Springs"}},
+			},
+		},
+		{
+			name: "Japanese keywords must be replied in the locale of the request",
+			req:  BotRequest{Text: "ガチマ", Locale: LocaleEN},
+			wantCards: []cardSummary{
+				{ModeName: "Anarchy Battle (Series)", Title: "Splat Zones", Lines: []string{"Hagglefish Market", "Undertow Spillway"}},
+			},
+		},
+		{
+			name: "今 must return slots of all modes",
+			req:  BotRequest{Text: "今"},
+			wantCards: []cardSummary{
+				{ModeName: "レギュラーマッチ", Title: "ナワバリバトル", Lines: []string{"ユノハナ大渓谷", "ゴンズイ地区"}},
+				{ModeName: "バンカラマッチ（オープン）", Title: "ガチヤグラ", Lines: []string{"ナメロウ金属", "クサヤ温泉"}},
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチエリア", Lines: []string{"ヤガラ市場", "マテガイ放水路"}},
+				{ModeName: "Xマッチ", Title: "ガチホコバトル", Lines: []string{"ヒラメが丘団地", "マサバ海峡大橋"}},
+				{ModeName: "サーモンラン", Title: "シェケナダム", Lines: []string{"スプラシューター", "パブロ", "リッター4K", "ヒッセン"}},
+			},
+		},
+		{
+			name: "/next must return slots of all modes in the next rotation",
+			req:  BotRequest{Command: "next"},
+			wantCards: []cardSummary{
+				{ModeName: "レギュラーマッチ", Title: "ナワバリバトル", Lines: []string{"ヤガラ市場", "マテガイ放水路"}},
+				{ModeName: "バンカラマッチ（オープン）", Title: "ガチホコバトル", Lines: []string{"ヒラメが丘団地", "マサバ海峡大橋"}},
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチヤグラ", Lines: []string{"ナメロウ金属", "クサヤ温泉"}},
+				{ModeName: "Xマッチ", Title: "ガチアサリ", Lines: []string{"キンメダイ美術館", "マヒマヒリゾート＆スパ"}},
+				{ModeName: "サーモンラン", Title: "アラマキ砦", Lines: []string{"ランダム", "ランダム", "ランダム", "ランダム"}},
+			},
+		},
+		{
+			name:        "now without mentions must be ignored",
+			req:         BotRequest{Text: "now"},
+			wantIgnored: true,
+		},
+		{
+			name:        "all without mentions must be ignored",
+			req:         BotRequest{Text: "all"},
+			wantIgnored: true,
+		},
+		{
+			name: "now with mentions must return slots of all modes",
+			req:  BotRequest{Text: "now", Mentioned: true},
+			wantCards: []cardSummary{
+				{ModeName: "Regular Battle", Title: "Turf War", Lines: []string{"Scorch Gorge", "Eeltail Alley"}},
+				{ModeName: "Anarchy Battle (Open)", Title: "Tower Control", Lines: []string{"Mincemeat Metalworks", "Brinewater Springs"}},
+				{ModeName: "Anarchy Battle (Series)", Title: "Splat Zones", Lines: []string{"Hagglefish Market", "Undertow Spillway"}},
+				{ModeName: "X Battle", Title: "Rainmaker", Lines: []string{"Flounder Heights", "Hammerhead Bridge"}},
+				{ModeName: "Salmon Run", Title: "Spawning Grounds", Lines: []string{"Splattershot", "Inkbrush", "E-liter 4K", "Tri-Slosher"}},
+			},
+		},
+		{
+			name:        "今 in a sentence must be ignored",
+			req:         BotRequest{Text: "ちょうど今"},
+			wantIgnored: true,
+		},
+		{
+			name:     "/table with unknown modes must be invalid",
+			req:      BotRequest{Command: "table", Options: map[string]string{"mode": "fest"}},
+			wantText: "Invalid command!",
+		},
+		{
+			name:     "misspelled keywords with mentions must be suggested",
+			req:      BotRequest{Text: "<@1018084105587544166> ガチアリ", Mentioned: true},
+			wantText: "もしかして: ガチアサリ？",
+		},
+		{
+			name:        "misspelled keywords without mentions must be ignored",
+			req:         BotRequest{Text: "ガチアリ"},
+			wantIgnored: true,
+		},
+		{
+			name:     "Not Found must be localized",
+			req:      BotRequest{Command: "ika", Options: map[string]string{"query": "5 時のガチマ"}, Locale: LocaleEN},
+			wantText: "Not Found!",
+		},
+		{
+			name:        "English keywords without mentions must be ignored",
+			req:         BotRequest{Text: "x match"},
+			wantIgnored: true,
+		},
+		{
+			name:        "English words in chats must be ignored",
+			req:         BotRequest{Text: "open"},
+			wantIgnored: true,
+		},
+		{
+			name:        "hiragana keywords in chats must be ignored",
+			req:         BotRequest{Text: "今日の晩ごはんはしゃけ"},
+			wantIgnored: true,
+		},
+		{
+			name: "hiragana keywords with mentions must be folded",
+			req:  BotRequest{Text: "次のしゃけ", Mentioned: true},
+			wantCards: []cardSummary{
+				{ModeName: "サーモンラン", Title: "アラマキ砦", Lines: []string{"ランダム", "ランダム", "ランダム", "ランダム"}},
+			},
+		},
+		{
+			name:        "asking the time with mentions must be ignored",
+			req:         BotRequest{Text: "今何時", Mentioned: true},
+			wantIgnored: true,
+		},
+		{
+			name:        "times without modes must be ignored",
+			req:         BotRequest{Text: "明日10時"},
+			wantIgnored: true,
+		},
+		{
+			name:        "unrelated text must be ignored",
+			req:         BotRequest{Text: "こんにちは"},
+			wantIgnored: true,
+		},
+		{
+			name:        "not found without mention must be ignored",
+			req:         BotRequest{Text: "5 時のガチマ"},
+			wantIgnored: true,
+		},
+		{
+			name:     "not found with mention must reply Not Found",
+			req:      BotRequest{Text: "5 時のガチマ", Mentioned: true},
+			wantText: "Not Found!",
+		},
+		{
+			name: "/x must return the current X Match slot",
+			req:  BotRequest{Command: "x"},
+			wantCards: []cardSummary{
+				{ModeName: "Xマッチ", Title: "ガチホコバトル", Lines: []string{"ヒラメが丘団地", "マサバ海峡大橋"}},
+			},
+		},
+		{
+			name: "/rule must return slots from Challenge, Open and X Match",
+			req:  BotRequest{Command: "rule", Options: map[string]string{"rule": "CLAM"}},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（チャレンジ）", Title: "ガチアサリ", Lines: []string{"キンメダイ美術館", "マヒマヒリゾート＆スパ"}},
+				{ModeName: "バンカラマッチ（オープン）", Title: "ガチアサリ", Lines: []string{"キンメダイ美術館", "マヒマヒリゾート＆スパ"}},
+				{ModeName: "Xマッチ", Title: "ガチアサリ", Lines: []string{"キンメダイ美術館", "マヒマヒリゾート＆スパ"}},
+			},
+		},
+		{
+			name: "/ika must be parsed as same as keywords",
+			req:  BotRequest{Command: "ika", Options: map[string]string{"query": "次の次のリグマ"}},
+			wantCards: []cardSummary{
+				{ModeName: "バンカラマッチ（オープン）", Title: "ガチアサリ", Lines: []string{"キンメダイ美術館", "マヒマヒリゾート＆スパ"}},
+			},
+		},
+		{
+			name:     "too many keywords must be rejected when mentioned",
+			req:      BotRequest{Text: "エリアとヤグラとホコとアサリ", Mentioned: true},
+			wantText: "キーワードは 3 個までです！",
+		},
+		{
+			name:        "too many keywords must be ignored in chats",
+			req:         BotRequest{Text: "エリアとヤグラとホコとアサリ"},
+			wantIgnored: true,
+		},
+		{
+			name:     "/ika with too many keywords must be rejected",
+			req:      BotRequest{Command: "ika", Options: map[string]string{"query": "エリアとヤグラとホコとアサリ"}, Locale: LocaleEN},
+			wantText: "Up to 3 keywords at once!",
+		},
+		{
+			name:     "unknown command must reply Invalid command",
+			req:      BotRequest{Command: "unknown"},
+			wantText: "Invalid command!",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewBot(newTestStore()).Handle(tt.req)
+			if got.Ignored != tt.wantIgnored {
+				t.Errorf("Handle().Ignored = %v, want %v", got.Ignored, tt.wantIgnored)
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("Handle().Text = %v, want %v", got.Text, tt.wantText)
+			}
+			var gotCards []cardSummary
+			for _, card := range got.Cards {
+				gotCards = append(gotCards, cardSummary{card.ModeName, card.Title, card.Lines, card.NotFound})
+			}
+			if !reflect.DeepEqual(gotCards, tt.wantCards) {
+				t.Errorf("Handle().Cards = %v, want %v", gotCards, tt.wantCards)
+			}
+		})
+	}
+}
+
+func TestResponseCard_Remaining(t *testing.T) {
+	card := ResponseCard{
+		StartTime: time.Date(2023, 3, 2, 11, 0, 0, 0, testJST),
+		EndTime:   time.Date(2023, 3, 2, 13, 0, 0, 0, testJST),
+	}
+	tests := []struct {
+		name   string
+		now    time.Time
+		locale string
+		want   string
+	}{
+		{name: "unknown time must be hidden", want: ""},
+		{name: "current slot must show the end", now: testNow, want: "終了まであと30分"},
+		{name: "next slot must show the start", now: testNow.Add(-time.Hour * 3), want: "開始まであと1時間30分"},
+		{name: "finished slot must be hidden", now: testNow.Add(time.Hour), want: ""},
+		{name: "remaining time must be localized", now: testNow, locale: LocaleEN, want: "ends in 30m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card.Now = tt.now
+			card.Locale = tt.locale
+			if got := card.Remaining(); got != tt.want {
+				t.Errorf("Remaining() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	card.Now, card.Locale, card.Question = testNow.Add(time.Hour), LocaleJA, QuestionUntil
+	if got := card.Period(); got != "終了しました" {
+		t.Errorf("questions for a finished slot must be answered: %v", got)
+	}
+}
+
+func TestBot_Handle_Period(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "current slot must show the remaining time", text: "ナワバリ", want: "3/2 11時～3/2 13時（終了まであと30分）"},
+		{name: "いつ must answer the start time", text: "次のガチマいつ？", want: "3/2 13時から（開始まであと30分）"},
+		{name: "いつ of the current slot must answer it is running", text: "ガチマいつ", want: "開催中（終了まであと30分）"},
+		{name: "いつまで must answer the end time", text: "Xのアサリはいつまで？", want: "3/2 15時まで（終了まであと2時間30分）"},
+		{name: "あと何分 must answer the remaining time", text: "ガチマあと何分", want: "終了まであと30分"},
+		{name: "English questions must be answered in English", text: "how long until next x battle", want: "starts in 30m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewBot(newTestStore()).Handle(BotRequest{Text: tt.text, Mentioned: true})
+			if len(resp.Cards) != 1 {
+				t.Fatalf("Handle().Cards = %v, Text = %v", resp.Cards, resp.Text)
+			}
+			if got := resp.Cards[0].Period(); got != tt.want {
+				t.Errorf("Period() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
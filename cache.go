@@ -97,8 +97,10 @@ func (fc *FileCache) MaybeGet(ttl time.Duration) interface{} {
 // XXX: to support generics
 func MaybeGetFromFileCache[T any](fc *FileCache, ttl time.Duration) *T {
 	if result := fc.MaybeGet(ttl); result == nil {
+		metricsFileCacheTotal.WithLabelValues(fc.CacheFileName, "miss").Inc()
 		return nil
 	} else {
+		metricsFileCacheTotal.WithLabelValues(fc.CacheFileName, "hit").Inc()
 		switch result.(type) {
 		case []interface{}:
 			var obj T
@@ -128,6 +130,12 @@ func MaybeGetFromFileCache[T any](fc *FileCache, ttl time.Duration) *T {
 	}
 }
 
+func (fc *FileCache) UpdatedAt() time.Time {
+	fc.RWMutex.RLock()
+	defer fc.RWMutex.RUnlock()
+	return fc.FileCacheBody.Updated
+}
+
 func (fc *FileCache) IsExpired(ttl time.Duration) bool {
 	fc.RWMutex.RLock()
 	defer fc.RWMutex.RUnlock()
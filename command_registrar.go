@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type CommandRegistrar struct {
+	sync.RWMutex
+	Session *discordgo.Session
+	AppID   string
+	// GuildID is empty for global commands
+	GuildID            string
+	registeredCommands []*discordgo.ApplicationCommand
+}
+
+type CommandDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (cd CommandDiff) IsEmpty() bool {
+	return len(cd.Added) == 0 && len(cd.Removed) == 0 && len(cd.Changed) == 0
+}
+
+func NewCommandRegistrar(session *discordgo.Session, appID string, guildID string) *CommandRegistrar {
+	return &CommandRegistrar{
+		Session: session,
+		AppID:   appID,
+		GuildID: guildID,
+	}
+}
+
+// canonicalizeCommand extracts fields defined by us; Discord fills IDs, versions and some defaults
+func canonicalizeCommand(cmd *discordgo.ApplicationCommand) string {
+	canonical := discordgo.ApplicationCommand{
+		Type:                     cmd.Type,
+		Name:                     cmd.Name,
+		NameLocalizations:        cmd.NameLocalizations,
+		DefaultMemberPermissions: cmd.DefaultMemberPermissions,
+		DMPermission:             cmd.DMPermission,
+		Description:              cmd.Description,
+		DescriptionLocalizations: cmd.DescriptionLocalizations,
+		Options:                  cmd.Options,
+	}
+	if canonical.Type == 0 {
+		canonical.Type = discordgo.ChatApplicationCommand
+	}
+	if canonical.DMPermission == nil {
+		allowed := true
+		canonical.DMPermission = &allowed
+	}
+	if canonical.NameLocalizations != nil && len(*canonical.NameLocalizations) == 0 {
+		canonical.NameLocalizations = nil
+	}
+	if canonical.DescriptionLocalizations != nil && len(*canonical.DescriptionLocalizations) == 0 {
+		canonical.DescriptionLocalizations = nil
+	}
+	bytes, err := json.Marshal(canonical)
+	if err != nil {
+		logger.Sugar().Errorf("Cannot marshal command '%v': %v", cmd.Name, err)
+		return ""
+	}
+	return string(bytes)
+}
+
+func diffCommands(existing []*discordgo.ApplicationCommand, desired []*discordgo.ApplicationCommand) CommandDiff {
+	var diff CommandDiff
+	existingByName := map[string]*discordgo.ApplicationCommand{}
+	for _, cmd := range existing {
+		existingByName[cmd.Name] = cmd
+	}
+	desiredByName := map[string]bool{}
+	for _, cmd := range desired {
+		desiredByName[cmd.Name] = true
+		current, found := existingByName[cmd.Name]
+		if !found {
+			diff.Added = append(diff.Added, cmd.Name)
+		} else if canonicalizeCommand(current) != canonicalizeCommand(cmd) {
+			diff.Changed = append(diff.Changed, cmd.Name)
+		}
+	}
+	for _, cmd := range existing {
+		if !desiredByName[cmd.Name] {
+			diff.Removed = append(diff.Removed, cmd.Name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// Sync overwrites commands only when their definitions are changed
+func (cr *CommandRegistrar) Sync(commands []*discordgo.ApplicationCommand) error {
+	existing, err := cr.Session.ApplicationCommands(cr.AppID, cr.GuildID)
+	if err != nil {
+		return err
+	}
+	diff := diffCommands(existing, commands)
+	if diff.IsEmpty() {
+		logger.Sugar().Infof("Commands are up to date (guild: '%s')", cr.GuildID)
+		cr.Lock()
+		cr.registeredCommands = existing
+		cr.Unlock()
+		return nil
+	}
+	logger.Sugar().Infof("Commands are outdated (guild: '%s'); added: %v, removed: %v, changed: %v", cr.GuildID, diff.Added, diff.Removed, diff.Changed)
+	registered, err := cr.Session.ApplicationCommandBulkOverwrite(cr.AppID, cr.GuildID, commands)
+	if err != nil {
+		return err
+	}
+	logger.Sugar().Infof("Overwrote %d commands", len(registered))
+	cr.Lock()
+	cr.registeredCommands = registered
+	cr.Unlock()
+	return nil
+}
+
+// IsRegistered reports whether all of the commands are registered
+func (cr *CommandRegistrar) IsRegistered(commands []*discordgo.ApplicationCommand) bool {
+	cr.RLock()
+	defer cr.RUnlock()
+	return diffCommands(cr.registeredCommands, commands).IsEmpty()
+}
+
+func (cr *CommandRegistrar) DeleteAll() {
+	cr.Lock()
+	defer cr.Unlock()
+	for _, val := range cr.registeredCommands {
+		err := cr.Session.ApplicationCommandDelete(cr.AppID, cr.GuildID, val.ID)
+		if err == nil {
+			logger.Sugar().Infof("Deleted a command '%#v'", val.Name)
+		} else {
+			metricsDiscordAPIErrorsTotal.WithLabelValues("delete_command").Inc()
+			logger.Sugar().Errorf("Cannot delete command '%v': %v", val.Name, err)
+		}
+	}
+	cr.registeredCommands = nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func Test_diffCommands(t *testing.T) {
+	// simulate a response from Discord with IDs and default values filled
+	var fetched []*discordgo.ApplicationCommand
+	bytes, _ := json.Marshal(slashCommands())
+	_ = json.Unmarshal(bytes, &fetched)
+	for _, cmd := range fetched {
+		allowed := true
+		cmd.ID = "1"
+		cmd.Version = "1"
+		cmd.Type = discordgo.ChatApplicationCommand
+		if cmd.DMPermission == nil {
+			cmd.DMPermission = &allowed
+		}
+	}
+	changed := *fetched[0]
+	changed.Description = "outdated description"
+
+	tests := []struct {
+		name     string
+		existing []*discordgo.ApplicationCommand
+		want     CommandDiff
+	}{
+		{
+			name:     "same definitions must be treated as no diff",
+			existing: fetched,
+			want:     CommandDiff{},
+		},
+		{
+			name:     "missing command must be treated as added",
+			existing: fetched[1:],
+			want:     CommandDiff{Added: []string{fetched[0].Name}},
+		},
+		{
+			name:     "unknown command must be treated as removed",
+			existing: append([]*discordgo.ApplicationCommand{{Name: "obsolete"}}, fetched...),
+			want:     CommandDiff{Removed: []string{"obsolete"}},
+		},
+		{
+			name:     "different description must be treated as changed",
+			existing: append([]*discordgo.ApplicationCommand{&changed}, fetched[1:]...),
+			want:     CommandDiff{Changed: []string{fetched[0].Name}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffCommands(tt.existing, slashCommands()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffCommands() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandRegistrar_IsRegistered(t *testing.T) {
+	var fetched []*discordgo.ApplicationCommand
+	bytes, _ := json.Marshal(slashCommands())
+	_ = json.Unmarshal(bytes, &fetched)
+
+	cr := NewCommandRegistrar(nil, "app", "")
+	if cr.IsRegistered(slashCommands()) {
+		t.Errorf("IsRegistered() must be false before syncing")
+	}
+	cr.registeredCommands = fetched
+	if !cr.IsRegistered(slashCommands()) {
+		t.Errorf("IsRegistered() must be true after syncing")
+	}
+	cr.registeredCommands = fetched[1:]
+	if cr.IsRegistered(slashCommands()) {
+		t.Errorf("IsRegistered() must be false when a command is missing")
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	manageGuildPermission int64 = discordgo.PermissionManageServer
+	dmNotAllowed                = false
+)
+
+func slashCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:        "regular",
+			Description: "Return a schedule for regular match",
+		},
+		{
+			Name:        "bankara",
+			Description: "Return a schedule for both Open and Challenge match",
+		},
+		{
+			Name:        "open",
+			Description: "Return a schedule for Open match",
+		},
+		{
+			Name:        "challenge",
+			Description: "Return a schedule for Challenge match",
+		},
+		{
+			Name:        "salmon",
+			Description: "Return a schedule for Salmon Run",
+		},
+		{
+			Name:        "x",
+			Description: "Return a schedule for X Match",
+		},
+		{
+			Name:        "now",
+			Description: "Return schedules of all modes running now",
+		},
+		{
+			Name:        "next",
+			Description: "Return schedules of all modes in the next rotation",
+		},
+		{
+			Name:        "rule",
+			Description: "Search both schedules from Open and Challenge match by rule name",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "rule",
+					Description: "a rule name to search",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name: "turf-war",
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.Japanese: "ナワバリバトル",
+							},
+							Value: "TURF_WAR",
+						},
+						{
+							Name: "area",
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.Japanese: "ガチエリア",
+							},
+							Value: "AREA",
+						},
+						{
+							Name: "rainmarker",
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.Japanese: "ガチホコバトル",
+							},
+							Value: "GOAL",
+						},
+						{
+							Name: "tower-control",
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.Japanese: "ガチヤグラ",
+							},
+							Value: "LOFT",
+						},
+						{
+							Name: "clam-blitz",
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.Japanese: "ガチアサリ",
+							},
+							Value: "CLAM",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "ika",
+			Description: "Search schedules by keywords as same as mentions (e.g. 次のガチマ)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "query",
+					Description: "keywords to search",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "explain",
+			Description: "Explain how keywords are parsed and searched for debugging",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "query",
+					Description: "keywords to explain",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "calendar",
+			Description: "Return a URL of iCalendar feed to subscribe schedules",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "mode",
+					Description: "a mode to filter",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "regular", Value: "REGULAR"},
+						{Name: "open", Value: "OPEN"},
+						{Name: "challenge", Value: "CHALLENGE"},
+						{Name: "x", Value: "X"},
+						{Name: "salmon", Value: "SALMON"},
+					},
+				},
+				{
+					Name:         "rule",
+					Description:  "a rule name to filter",
+					Type:         discordgo.ApplicationCommandOptionString,
+					Autocomplete: true,
+				},
+				{
+					Name:         "stage",
+					Description:  "a stage name to filter",
+					Type:         discordgo.ApplicationCommandOptionString,
+					Autocomplete: true,
+				},
+				{
+					Name:        "bigrun",
+					Description: "only Big Run",
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+				},
+			},
+		},
+		{
+			Name:        "table",
+			Description: "Return all upcoming schedules as a table",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "mode",
+					Description: "modes to show; all battles by default",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "all", Value: "ALL"},
+						{Name: "bankara", Value: "BANKARA"},
+						{Name: "regular", Value: "REGULAR"},
+						{Name: "open", Value: "OPEN"},
+						{Name: "challenge", Value: "CHALLENGE"},
+						{Name: "x", Value: "X"},
+						{Name: "salmon", Value: "SALMON"},
+					},
+				},
+				{
+					Name:        "format",
+					Description: "text by default",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "text", Value: "text"},
+						{Name: "image", Value: "image"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "config",
+			Description: "Show or change settings of the bot in this server",
+			// only for administrators by default; server owners can change it in the integration settings
+			DefaultMemberPermissions: &manageGuildPermission,
+			DMPermission:             &dmNotAllowed,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "mention_only",
+					Description: "reply only when the bot is mentioned",
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+				},
+				{
+					Name:        "default_mode",
+					Description: "a mode used for keywords without modes such as 次の",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "none", Value: "NONE"},
+						{Name: "regular", Value: "REGULAR"},
+						{Name: "bankara", Value: "BANKARA"},
+						{Name: "open", Value: "OPEN"},
+						{Name: "challenge", Value: "CHALLENGE"},
+						{Name: "x", Value: "X"},
+						{Name: "salmon", Value: "SALMON"},
+					},
+				},
+				{
+					Name:        "locale",
+					Description: "a language of replies",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "日本語", Value: "ja"},
+						{Name: "English", Value: "en"},
+					},
+				},
+				{
+					Name:        "channel_mode",
+					Description: "how to treat keywords in the channel",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "all messages", Value: ListenAll},
+						{Name: "mentions and prefix only", Value: ListenMention},
+						{Name: "ignore", Value: ListenIgnore},
+						{Name: "follow mention_only", Value: "default"},
+					},
+				},
+				{
+					Name:         "channel",
+					Description:  "a channel to apply channel_mode; defaults to this channel",
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+				{
+					Name:        "prefix",
+					Description: "a prefix to call the bot without mentions such as !ika; none to disable",
+					Type:        discordgo.ApplicationCommandOptionString,
+				},
+				{
+					Name:        "reset",
+					Description: "reset all settings to the defaults",
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+				},
+			},
+		},
+	}
+}
@@ -1,7 +1,363 @@
 package main
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
 var (
-	USER_AGENT      = "ikabot3/0.1 (github.com/k5342)"
 	IDENTIFIER_NEXT = "次の"
 	IDENTIFIER_PREV = "前の"
 )
+
+type DiscordConfig struct {
+	// Enabled is true by default; disable it to run only other adapters
+	Enabled                   bool   `yaml:"enabled"`
+	Token                     string `yaml:"token"`
+	AllowMessageContentIntent bool   `yaml:"allow_message_content_intent"`
+	// CommandGuildID registers commands to the guild instead of global if not empty
+	CommandGuildID       string `yaml:"command_guild_id"`
+	DeleteCommandsOnExit bool   `yaml:"delete_commands_on_exit"`
+	// BannerImages attaches composed images of stages and weapons to replies
+	BannerImages bool `yaml:"banner_images"`
+}
+
+type SourcesConfig struct {
+	Schedule string `yaml:"schedule"`
+	// Salmon is derived from Schedule if empty
+	Salmon string `yaml:"salmon"`
+}
+
+type CacheConfig struct {
+	Dir       string        `yaml:"dir"`
+	TTL       time.Duration `yaml:"ttl"`
+	SalmonTTL time.Duration `yaml:"salmon_ttl"`
+}
+
+type HTTPConfig struct {
+	Addr        string        `yaml:"addr"`
+	PublicURL   string        `yaml:"public_url"`
+	ReadyMaxAge time.Duration `yaml:"ready_max_age"`
+}
+
+type SlackConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	SigningSecret string `yaml:"signing_secret"`
+	BotToken      string `yaml:"bot_token"`
+	ListenAddr    string `yaml:"listen_addr"`
+}
+
+type MatrixConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	HomeserverURL string   `yaml:"homeserver"`
+	AccessToken   string   `yaml:"access_token"`
+	UserID        string   `yaml:"user_id"`
+	Keywords      []string `yaml:"keywords"`
+}
+
+type IRCConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Server   string   `yaml:"server"`
+	UseTLS   bool     `yaml:"tls"`
+	Password string   `yaml:"password"`
+	Nick     string   `yaml:"nick"`
+	Channels []string `yaml:"channels"`
+}
+
+type WebhookAdapterConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// File is a JSON file loaded by LoadWebhookConfig
+	File string `yaml:"file"`
+}
+
+type AdaptersConfig struct {
+	Slack   SlackConfig          `yaml:"slack"`
+	Matrix  MatrixConfig         `yaml:"matrix"`
+	IRC     IRCConfig            `yaml:"irc"`
+	Webhook WebhookAdapterConfig `yaml:"webhook"`
+}
+
+type Config struct {
+	Discord   DiscordConfig  `yaml:"discord"`
+	Sources   SourcesConfig  `yaml:"sources"`
+	UserAgent string         `yaml:"user_agent"`
+	Cache     CacheConfig    `yaml:"cache"`
+	HTTP      HTTPConfig     `yaml:"http"`
+	LogLevel  string         `yaml:"log_level"`
+	Adapters  AdaptersConfig `yaml:"adapters"`
+	// Guilds holds default settings keyed by guild IDs
+	Guilds map[string]GuildSettings `yaml:"guilds"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Discord: DiscordConfig{
+			Enabled: true,
+		},
+		UserAgent: "ikabot3/0.1 (github.com/k5342)",
+		Cache: CacheConfig{
+			Dir:       "./",
+			TTL:       time.Minute * 30,
+			SalmonTTL: time.Minute * 30,
+		},
+		HTTP: HTTPConfig{
+			ReadyMaxAge: time.Hour * 2,
+		},
+		LogLevel: "info",
+		Adapters: AdaptersConfig{
+			Slack: SlackConfig{
+				ListenAddr: ":3000",
+			},
+			IRC: IRCConfig{
+				UseTLS: true,
+				Nick:   "ikabot3",
+			},
+			Webhook: WebhookAdapterConfig{
+				File: "webhooks.json",
+			},
+		},
+	}
+}
+
+func (c *Config) applyEnv(getenv func(string) string) error {
+	setString := func(name string, dest *string) {
+		if value := getenv(name); value != "" {
+			*dest = value
+		}
+	}
+	setBool := func(name string, dest *bool) {
+		if value := getenv(name); value != "" {
+			*dest = value == "TRUE"
+		}
+	}
+	setList := func(name string, dest *[]string) {
+		if value := getenv(name); value != "" {
+			*dest = splitList(value)
+		}
+	}
+	setDuration := func(name string, dest *time.Duration) error {
+		if value := getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			*dest = d
+		}
+		return nil
+	}
+
+	setBool("IKABOT3_DISCORD_ENABLED", &c.Discord.Enabled)
+	setString("IKABOT3_TOKEN", &c.Discord.Token)
+	setBool("IKABOT3_ALLOW_MESSAGE_CONTENT_INTENT", &c.Discord.AllowMessageContentIntent)
+	setString("IKABOT3_COMMAND_GUILD_ID", &c.Discord.CommandGuildID)
+	setBool("IKABOT3_DELETE_COMMANDS_ON_EXIT", &c.Discord.DeleteCommandsOnExit)
+	setBool("IKABOT3_BANNER_IMAGES", &c.Discord.BannerImages)
+	setString("IKABOT3_API_SOURCE", &c.Sources.Schedule)
+	setString("IKABOT3_API_SOURCE_SALMON", &c.Sources.Salmon)
+	setString("IKABOT3_USER_AGENT", &c.UserAgent)
+	setString("IKABOT3_CACHE_DIR", &c.Cache.Dir)
+	setString("IKABOT3_HTTP_ADDR", &c.HTTP.Addr)
+	setString("IKABOT3_PUBLIC_URL", &c.HTTP.PublicURL)
+	setString("IKABOT3_LOG_LEVEL", &c.LogLevel)
+	for name, dest := range map[string]*time.Duration{
+		"IKABOT3_CACHE_TTL":        &c.Cache.TTL,
+		"IKABOT3_CACHE_SALMON_TTL": &c.Cache.SalmonTTL,
+		"IKABOT3_READY_MAX_AGE":    &c.HTTP.ReadyMaxAge,
+	} {
+		if err := setDuration(name, dest); err != nil {
+			return err
+		}
+	}
+
+	// adapters are enabled by giving their credentials as before
+	if getenv("IKABOT3_SLACK_SIGNING_SECRET") != "" {
+		c.Adapters.Slack.Enabled = true
+	}
+	setString("IKABOT3_SLACK_SIGNING_SECRET", &c.Adapters.Slack.SigningSecret)
+	setString("IKABOT3_SLACK_BOT_TOKEN", &c.Adapters.Slack.BotToken)
+	setString("IKABOT3_SLACK_LISTEN_ADDR", &c.Adapters.Slack.ListenAddr)
+	if getenv("IKABOT3_MATRIX_HOMESERVER") != "" {
+		c.Adapters.Matrix.Enabled = true
+	}
+	setString("IKABOT3_MATRIX_HOMESERVER", &c.Adapters.Matrix.HomeserverURL)
+	setString("IKABOT3_MATRIX_ACCESS_TOKEN", &c.Adapters.Matrix.AccessToken)
+	setString("IKABOT3_MATRIX_USER_ID", &c.Adapters.Matrix.UserID)
+	setList("IKABOT3_MATRIX_KEYWORDS", &c.Adapters.Matrix.Keywords)
+	if getenv("IKABOT3_IRC_SERVER") != "" {
+		c.Adapters.IRC.Enabled = true
+	}
+	setString("IKABOT3_IRC_SERVER", &c.Adapters.IRC.Server)
+	setBool("IKABOT3_IRC_TLS", &c.Adapters.IRC.UseTLS)
+	setString("IKABOT3_IRC_PASSWORD", &c.Adapters.IRC.Password)
+	setString("IKABOT3_IRC_NICK", &c.Adapters.IRC.Nick)
+	setList("IKABOT3_IRC_CHANNELS", &c.Adapters.IRC.Channels)
+	if getenv("IKABOT3_WEBHOOK_CONFIG") != "" {
+		c.Adapters.Webhook.Enabled = true
+	}
+	setString("IKABOT3_WEBHOOK_CONFIG", &c.Adapters.Webhook.File)
+	return nil
+}
+
+// Validate reports all problems at once to fix the config file in a single pass
+func (c *Config) Validate() error {
+	var problems []string
+	requires := func(enabled bool, fields map[string]string) {
+		if !enabled {
+			return
+		}
+		var names []string
+		for name, value := range fields {
+			if value == "" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			problems = append(problems, name+" is required")
+		}
+	}
+
+	requires(c.Discord.Enabled, map[string]string{
+		"discord.token": c.Discord.Token,
+	})
+	requires(true, map[string]string{
+		"sources.schedule": c.Sources.Schedule,
+		"user_agent":       c.UserAgent,
+		"cache.dir":        c.Cache.Dir,
+	})
+	for name, source := range map[string]string{"sources.schedule": c.Sources.Schedule, "sources.salmon": c.Sources.Salmon} {
+		if source == "" {
+			continue
+		}
+		if u, err := url.Parse(source); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			problems = append(problems, fmt.Sprintf("%s must be an http(s) URL: '%s'", name, source))
+		}
+	}
+	if c.Cache.TTL <= 0 {
+		problems = append(problems, "cache.ttl must be positive")
+	}
+	if c.Cache.SalmonTTL <= 0 {
+		problems = append(problems, "cache.salmon_ttl must be positive")
+	}
+	if c.HTTP.ReadyMaxAge <= 0 {
+		problems = append(problems, "http.ready_max_age must be positive")
+	}
+	for guildID, settings := range c.Guilds {
+		if err := settings.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("guilds.%s: %v", guildID, err))
+		}
+	}
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		problems = append(problems, fmt.Sprintf("log_level is unknown: '%s'", c.LogLevel))
+	}
+	requires(c.Adapters.Slack.Enabled, map[string]string{
+		"adapters.slack.signing_secret": c.Adapters.Slack.SigningSecret,
+		"adapters.slack.listen_addr":    c.Adapters.Slack.ListenAddr,
+	})
+	requires(c.Adapters.Matrix.Enabled, map[string]string{
+		"adapters.matrix.homeserver":   c.Adapters.Matrix.HomeserverURL,
+		"adapters.matrix.access_token": c.Adapters.Matrix.AccessToken,
+		"adapters.matrix.user_id":      c.Adapters.Matrix.UserID,
+	})
+	requires(c.Adapters.IRC.Enabled, map[string]string{
+		"adapters.irc.server": c.Adapters.IRC.Server,
+		"adapters.irc.nick":   c.Adapters.IRC.Nick,
+	})
+	requires(c.Adapters.Webhook.Enabled, map[string]string{
+		"adapters.webhook.file": c.Adapters.Webhook.File,
+	})
+
+	sort.Strings(problems)
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// LoadConfig reads the YAML file if given, then overrides it by environment variables
+func LoadConfig(filename string, getenv func(string) string) (*Config, error) {
+	config := DefaultConfig()
+	if filename != "" {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		// reject typos in keys
+		decoder.KnownFields(true)
+		err = decoder.Decode(config)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	err := config.applyEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+	for guildID, settings := range config.Guilds {
+		config.Guilds[guildID] = settings.Normalize()
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// RestartRequired lists changed settings which cannot be applied by reloading
+func (c *Config) RestartRequired(newer *Config) []string {
+	var changed []string
+	if c.Discord != newer.Discord {
+		changed = append(changed, "discord")
+	}
+	if c.Cache.Dir != newer.Cache.Dir {
+		changed = append(changed, "cache.dir")
+	}
+	if c.HTTP.Addr != newer.HTTP.Addr {
+		changed = append(changed, "http.addr")
+	}
+	if c.HTTP.PublicURL != newer.HTTP.PublicURL {
+		changed = append(changed, "http.public_url")
+	}
+	current, _ := yaml.Marshal(c.Adapters)
+	next, _ := yaml.Marshal(newer.Adapters)
+	if string(current) != string(next) {
+		changed = append(changed, "adapters")
+	}
+	return changed
+}
+
+func (c *Config) Guild(guildID string) GuildSettings {
+	return c.Guilds[guildID]
+}
+
+func (c *Config) SalmonSource() (string, error) {
+	if c.Sources.Salmon != "" {
+		return c.Sources.Salmon, nil
+	}
+	return url.JoinPath(c.Sources.Schedule, "..", "coop-grouping-regular/schedule")
+}
+
+var currentConfig atomic.Pointer[Config]
+
+// getConfig returns the active config; defaults are used until the config is loaded
+func getConfig() *Config {
+	if config := currentConfig.Load(); config != nil {
+		return config
+	}
+	return DefaultConfig()
+}
+
+func setConfig(config *Config) {
+	currentConfig.Store(config)
+}
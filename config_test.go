@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(filename, []byte(`
+discord:
+  token: file-token
+sources:
+  schedule: https://example.com/api/schedule
+cache:
+  ttl: 10m
+adapters:
+  irc:
+    enabled: true
+    server: irc.example.com:6697
+    nick: ikabot3
+guilds:
+  "123":
+    mention_only: true
+    default_mode: challenge
+    locale: EN
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"IKABOT3_TOKEN":        "env-token",
+		"IKABOT3_IRC_CHANNELS": "#a, #b",
+	}
+	config, err := LoadConfig(filename, func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Discord.Token != "env-token" {
+		t.Errorf("environment variables must override the file; got %v", config.Discord.Token)
+	}
+	if config.Cache.TTL != time.Minute*10 || config.Cache.SalmonTTL != time.Minute*30 {
+		t.Errorf("TTLs = %v, %v, want 10m, 30m", config.Cache.TTL, config.Cache.SalmonTTL)
+	}
+	if !reflect.DeepEqual(config.Adapters.IRC.Channels, []string{"#a", "#b"}) {
+		t.Errorf("IRC channels = %v", config.Adapters.IRC.Channels)
+	}
+	if !config.Guild("123").MentionOnly || config.Guild("456").MentionOnly {
+		t.Errorf("guild defaults are not applied: %v", config.Guilds)
+	}
+	if guild := config.Guild("123"); guild.DefaultMode != "CHALLENGE" || guild.Locale != "en" {
+		t.Errorf("guild settings must be normalized; got %v", guild)
+	}
+	salmon, _ := config.SalmonSource()
+	if salmon != "https://example.com/api/coop-grouping-regular/schedule" {
+		t.Errorf("SalmonSource() = %v", salmon)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		env      map[string]string
+		wantErrs []string
+	}{
+		{
+			name:     "missing required values must be listed together",
+			yaml:     "log_level: verbose\n",
+			wantErrs: []string{"discord.token is required", "sources.schedule is required", "log_level is unknown: 'verbose'"},
+		},
+		{
+			name:     "enabled adapters must have their credentials",
+			yaml:     "discord: {token: t}\nsources: {schedule: 'https://example.com/'}\nadapters: {matrix: {enabled: true, homeserver: 'https://matrix.example.com'}}\n",
+			wantErrs: []string{"adapters.matrix.access_token is required", "adapters.matrix.user_id is required"},
+		},
+		{
+			name:     "enabled slack must have a listen address",
+			yaml:     "discord: {enabled: false}\nsources: {schedule: 'https://example.com/'}\nadapters: {slack: {enabled: true, signing_secret: s, bot_token: b, listen_addr: ''}}\n",
+			wantErrs: []string{"adapters.slack.listen_addr is required"},
+		},
+		{
+			name:     "unknown keys must be rejected",
+			yaml:     "discord: {tokn: t}\n",
+			wantErrs: []string{"field tokn not found"},
+		},
+		{
+			name:     "malformed durations in environment variables must be rejected",
+			yaml:     "discord: {token: t}\nsources: {schedule: 'https://example.com/'}\n",
+			env:      map[string]string{"IKABOT3_CACHE_TTL": "soon"},
+			wantErrs: []string{"IKABOT3_CACHE_TTL"},
+		},
+		{
+			name:     "non-http sources must be rejected",
+			yaml:     "discord: {token: t}\nsources: {schedule: 'file:///etc/passwd'}\n",
+			wantErrs: []string{"sources.schedule must be an http(s) URL"},
+		},
+		{
+			name:     "unknown guild settings must be rejected",
+			yaml:     "discord: {token: t}\nsources: {schedule: 'https://example.com/'}\nguilds: {'1': {default_mode: turf}}\n",
+			wantErrs: []string{"guilds.1: unknown default_mode 'TURF'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(filename, []byte(tt.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfig(filename, func(name string) string { return tt.env[name] })
+			if err == nil {
+				t.Fatalf("LoadConfig() must fail")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error must contain %v; got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_RestartRequired(t *testing.T) {
+	current := DefaultConfig()
+	newer := DefaultConfig()
+	newer.LogLevel = "debug"
+	newer.Cache.TTL = time.Minute
+	if changed := current.RestartRequired(newer); len(changed) != 0 {
+		t.Errorf("reloadable changes must not require restart; got %v", changed)
+	}
+	newer.Discord.Token = "another"
+	newer.Adapters.IRC.Channels = []string{"#c"}
+	if changed := current.RestartRequired(newer); !reflect.DeepEqual(changed, []string{"discord", "adapters"}) {
+		t.Errorf("RestartRequired() = %v", changed)
+	}
+}
+
+func TestLoadConfig_Example(t *testing.T) {
+	env := map[string]string{
+		"IKABOT3_TOKEN":      "token",
+		"IKABOT3_API_SOURCE": "https://example.com/api/schedule",
+	}
+	if _, err := LoadConfig("config.example.yaml", func(name string) string { return env[name] }); err != nil {
+		t.Errorf("config.example.yaml must be valid: %v", err)
+	}
+}
+
+func TestLoadConfig_WithoutDiscord(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filename, []byte("discord: {enabled: false}\nsources: {schedule: 'https://example.com/'}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"IKABOT3_SLACK_SIGNING_SECRET": "secret", "IKABOT3_SLACK_BOT_TOKEN": "token"}
+	config, err := LoadConfig(filename, func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatalf("disabled discord must not require a token: %v", err)
+	}
+	if !config.Adapters.Slack.Enabled || config.Adapters.Slack.ListenAddr != ":3000" {
+		t.Errorf("slack must listen on the default address; got %+v", config.Adapters.Slack)
+	}
+}
+
+func TestDefaultConfig_Example(t *testing.T) {
+	data, err := os.ReadFile("config.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	example := DefaultConfig()
+	if err := yaml.Unmarshal(data, example); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprintf("%+v", *example), fmt.Sprintf("%+v", *DefaultConfig()); got != want {
+		t.Errorf("config.example.yaml must agree with the defaults;\ngot  %v\nwant %v", got, want)
+	}
+}
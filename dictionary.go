@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type DictionaryEntry struct {
+	// Name is a Japanese name used by the upstream API
+	Name        string
+	EnglishName string
+	// Reading is a katakana reading of Name (only needed if Name contains kanji)
+	Reading string
+	// Value is passed as a value of autocomplete choices; defaults to Name
+	Value string
+}
+
+func (de DictionaryEntry) getValue() string {
+	if de.Value != "" {
+		return de.Value
+	}
+	return de.Name
+}
+
+func (de DictionaryEntry) getLabel() string {
+	if de.EnglishName == "" {
+		return de.Name
+	}
+	return de.Name + " (" + de.EnglishName + ")"
+}
+
+var RuleDictionary = []DictionaryEntry{
+	{Name: "ナワバリバトル", EnglishName: "Turf War", Value: "TURF_WAR"},
+	{Name: "ガチエリア", EnglishName: "Splat Zones", Value: "AREA"},
+	{Name: "ガチヤグラ", EnglishName: "Tower Control", Value: "LOFT"},
+	{Name: "ガチホコバトル", EnglishName: "Rainmaker", Value: "GOAL"},
+	{Name: "ガチアサリ", EnglishName: "Clam Blitz", Value: "CLAM"},
+}
+
+var StageDictionary = []DictionaryEntry{
+	{Name: "ユノハナ大渓谷", EnglishName: "Scorch Gorge", Reading: "ユノハナダイケイコク"},
+	{Name: "ゴンズイ地区", EnglishName: "Eeltail Alley", Reading: "ゴンズイチク"},
+	{Name: "ヤガラ市場", EnglishName: "Hagglefish Market", Reading: "ヤガライチバ"},
+	{Name: "マテガイ放水路", EnglishName: "Undertow Spillway", Reading: "マテガイホウスイロ"},
+	{Name: "ナメロウ金属", EnglishName: "Mincemeat Metalworks", Reading: "ナメロウキンゾク"},
+	{Name: "クサヤ温泉", EnglishName: "Brinewater Springs", Reading: "クサヤオンセン"},
+	{Name: "ヒラメが丘団地", EnglishName: "Flounder Heights", Reading: "ヒラメガオカダンチ"},
+	{Name: "マサバ海峡大橋", EnglishName: "Hammerhead Bridge", Reading: "マサバカイキョウオオハシ"},
+	{Name: "キンメダイ美術館", EnglishName: "Museum d'Alfonsino", Reading: "キンメダイビジュツカン"},
+	{Name: "マヒマヒリゾート＆スパ", EnglishName: "Mahi-Mahi Resort"},
+	{Name: "海女美術大学", EnglishName: "Inkblot Art Academy", Reading: "アマビジュツダイガク"},
+	{Name: "チョウザメ造船", EnglishName: "Sturgeon Shipyard", Reading: "チョウザメゾウセン"},
+	{Name: "ザトウマーケット", EnglishName: "MakoMart"},
+	{Name: "スメーシーワールド", EnglishName: "Wahoo World"},
+	{Name: "ナンプラー遺跡", EnglishName: "Um'ami Ruins", Reading: "ナンプラーイセキ"},
+	{Name: "マンタマリア号", EnglishName: "Manta Maria", Reading: "マンタマリアゴウ"},
+	{Name: "タラポートショッピングパーク", EnglishName: "Barnacle & Dime"},
+	{Name: "コンブトラック", EnglishName: "Humpback Pump Track"},
+	{Name: "タカアシ経済特区", EnglishName: "Crableg Capital", Reading: "タカアシケイザイトック"},
+	{Name: "オヒョウ海運", EnglishName: "Shipshape Cargo Co.", Reading: "オヒョウカイウン"},
+	{Name: "バイガイ亭", EnglishName: "Robo ROM-en", Reading: "バイガイテイ"},
+	{Name: "ネギトロ炭鉱", EnglishName: "Bluefin Depot", Reading: "ネギトロタンコウ"},
+	{Name: "カジキ空港", EnglishName: "Marlin Airport", Reading: "カジキクウコウ"},
+	{Name: "リュウグウターミナル", EnglishName: "Lemuria Hub"},
+	{Name: "デカライン高架下", EnglishName: "Urchin Underpass", Reading: "デカラインコウカシタ"},
+	// Salmon Run
+	{Name: "シェケナダム", EnglishName: "Spawning Grounds"},
+	{Name: "アラマキ砦", EnglishName: "Sockeye Station", Reading: "アラマキトリデ"},
+	{Name: "ムニ・エール海洋発電所", EnglishName: "Marooner's Bay", Reading: "ムニエールカイヨウハツデンショ"},
+	{Name: "すじこジャンクション跡", EnglishName: "Jammin' Salmon Junction", Reading: "スジコジャンクションアト"},
+	{Name: "トキシラズいぶし工房", EnglishName: "Salmonid Smokeyard", Reading: "トキシラズイブシコウボウ"},
+	{Name: "どんぴこ闘技場", EnglishName: "Bonerattle Arena", Reading: "ドンピコトウギジョウ"},
+}
+
+var WeaponDictionary = []DictionaryEntry{
+	{Name: "わかばシューター", EnglishName: "Splattershot Jr."},
+	{Name: "スプラシューター", EnglishName: "Splattershot"},
+	{Name: "プロモデラーMG", EnglishName: "Aerospray MG"},
+	{Name: "シャープマーカー", EnglishName: "Splash-o-matic"},
+	{Name: "ボールドマーカー", EnglishName: "Sploosh-o-matic"},
+	{Name: ".52ガロン", EnglishName: ".52 Gal"},
+	{Name: ".96ガロン", EnglishName: ".96 Gal"},
+	{Name: "N-ZAP85", EnglishName: "N-ZAP '85"},
+	{Name: "プライムシューター", EnglishName: "Splattershot Pro"},
+	{Name: "ジェットスイーパー", EnglishName: "Jet Squelcher"},
+	{Name: "L3リールガン", EnglishName: "L-3 Nozzlenose"},
+	{Name: "H3リールガン", EnglishName: "H-3 Nozzlenose"},
+	{Name: "ボトルガイザー", EnglishName: "Squeezer"},
+	{Name: "スペースシューター", EnglishName: "Splattershot Nova"},
+	{Name: "ノヴァブラスター", EnglishName: "Luna Blaster"},
+	{Name: "ホットブラスター", EnglishName: "Blaster"},
+	{Name: "ロングブラスター", EnglishName: "Range Blaster"},
+	{Name: "ラピッドブラスター", EnglishName: "Rapid Blaster"},
+	{Name: "Rブラスターエリート", EnglishName: "Rapid Blaster Pro"},
+	{Name: "クラッシュブラスター", EnglishName: "Clash Blaster"},
+	{Name: "スプラローラー", EnglishName: "Splat Roller"},
+	{Name: "カーボンローラー", EnglishName: "Carbon Roller"},
+	{Name: "ダイナモローラー", EnglishName: "Dynamo Roller"},
+	{Name: "ヴァリアブルローラー", EnglishName: "Flingza Roller"},
+	{Name: "ワイドローラー", EnglishName: "Big Swig Roller"},
+	{Name: "パブロ", EnglishName: "Inkbrush"},
+	{Name: "ホクサイ", EnglishName: "Octobrush"},
+	{Name: "フィンセント", EnglishName: "Painbrush"},
+	{Name: "スプラチャージャー", EnglishName: "Splat Charger"},
+	{Name: "スクイックリンα", EnglishName: "Classic Squiffer"},
+	{Name: "リッター4K", EnglishName: "E-liter 4K"},
+	{Name: "14式竹筒銃・甲", EnglishName: "Bamboozler 14 Mk I", Reading: "14シキタケヅツジュウコウ"},
+	{Name: "ソイチューバー", EnglishName: "Goo Tuber"},
+	{Name: "R-PEN/5H", EnglishName: "Snipewriter 5H"},
+	{Name: "バケットスロッシャー", EnglishName: "Slosher"},
+	{Name: "ヒッセン", EnglishName: "Tri-Slosher"},
+	{Name: "スクリュースロッシャー", EnglishName: "Sloshing Machine"},
+	{Name: "オーバーフロッシャー", EnglishName: "Bloblobber"},
+	{Name: "エクスプロッシャー", EnglishName: "Explosher"},
+	{Name: "モップリン", EnglishName: "Dread Wringer"},
+	{Name: "スプラスピナー", EnglishName: "Mini Splatling"},
+	{Name: "バレルスピナー", EnglishName: "Heavy Splatling"},
+	{Name: "ハイドラント", EnglishName: "Hydra Splatling"},
+	{Name: "クーゲルシュライバー", EnglishName: "Ballpoint Splatling"},
+	{Name: "ノーチラス47", EnglishName: "Nautilus 47"},
+	{Name: "イグザミナー", EnglishName: "Heavy Edit Splatling"},
+	{Name: "スパッタリー", EnglishName: "Dapple Dualies"},
+	{Name: "スプラマニューバー", EnglishName: "Splat Dualies"},
+	{Name: "ケルビン525", EnglishName: "Glooga Dualies"},
+	{Name: "デュアルスイーパー", EnglishName: "Dualie Squelchers"},
+	{Name: "クアッドホッパーブラック", EnglishName: "Dark Tetra Dualies"},
+	{Name: "ガエンFF", EnglishName: "Douser Dualies FF"},
+	{Name: "パラシェルター", EnglishName: "Splat Brella"},
+	{Name: "キャンピングシェルター", EnglishName: "Tenta Brella"},
+	{Name: "スパイガジェット", EnglishName: "Undercover Brella"},
+	{Name: "24式張替傘・甲", EnglishName: "Recycled Brella 24 Mk I", Reading: "24シキハリカエガサコウ"},
+	{Name: "トライストリンガー", EnglishName: "Tri-Stringer"},
+	{Name: "LACT-450", EnglishName: "REEF-LUX 450"},
+	{Name: "フルイドV", EnglishName: "Wellstring V"},
+	{Name: "ジムワイパー", EnglishName: "Splatana Wiper"},
+	{Name: "ドライブワイパー", EnglishName: "Splatana Stamper"},
+	{Name: "デンタルワイパーミント", EnglishName: "Mint Decavitator"},
+	// Salmon Run
+	{Name: "ランダム", EnglishName: "Random"},
+}
+
+// toKatakana converts hiragana in the input into katakana
+func toKatakana(input string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ぁ' && r <= 'ゖ' {
+			return r + ('ァ' - 'ぁ')
+		}
+		return r
+	}, input)
+}
+
+// normalizeForMatch folds case, kana type and separators to compare names loosely
+func normalizeForMatch(input string) string {
+	input = toKatakana(strings.ToLower(input))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '・' || r == '-' || r == '\'' || r == '.' {
+			return -1
+		}
+		return r
+	}, input)
+}
+
+// matchScore returns 2 for prefix match, 1 for partial match, and 0 for no match
+func (de DictionaryEntry) matchScore(normalizedInput string) int {
+	if normalizedInput == "" {
+		return 1
+	}
+	score := 0
+	for _, candidate := range []string{de.Name, de.EnglishName, de.Reading} {
+		if candidate == "" {
+			continue
+		}
+		c := normalizeForMatch(candidate)
+		if strings.HasPrefix(c, normalizedInput) {
+			return 2
+		}
+		if strings.Contains(c, normalizedInput) {
+			score = 1
+		}
+	}
+	return score
+}
+
+// mergeDictionary lists names seen in the current schedule first, followed by the rest of the static dictionary
+func mergeDictionary(dictionary []DictionaryEntry, names []string) []DictionaryEntry {
+	merged := make([]DictionaryEntry, 0, len(names)+len(dictionary))
+	known := map[string]DictionaryEntry{}
+	for _, entry := range dictionary {
+		known[entry.Name] = entry
+	}
+	added := map[string]bool{}
+	for _, name := range names {
+		if added[name] {
+			continue
+		}
+		entry, found := known[name]
+		if !found {
+			entry = DictionaryEntry{Name: name}
+		}
+		merged = append(merged, entry)
+		added[name] = true
+	}
+	for _, entry := range dictionary {
+		if !added[entry.Name] {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+// completeDictionary returns entries matched with the input ordered by relevance
+func completeDictionary(dictionary []DictionaryEntry, input string, limit int) []DictionaryEntry {
+	normalized := normalizeForMatch(input)
+	type scored struct {
+		entry DictionaryEntry
+		score int
+	}
+	var candidates []scored
+	for _, entry := range dictionary {
+		if score := entry.matchScore(normalized); score > 0 {
+			candidates = append(candidates, scored{entry, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	var results []DictionaryEntry
+	for _, c := range candidates {
+		if len(results) >= limit {
+			break
+		}
+		results = append(results, c.entry)
+	}
+	return results
+}
+
+// UnknownNameError is returned for inputs which are not names in the dictionary; Suggestion is the closest name if any
+type UnknownNameError struct {
+	Input      string
+	Suggestion string
+}
+
+func (e *UnknownNameError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown name %q; did you mean %q?", e.Input, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown name %q", e.Input)
+}
+
+// resolveDictionaryValue maps an input into the value of the entry whose value or names equal it loosely;
+// partial matches are never guessed but given as a suggestion of UnknownNameError
+func resolveDictionaryValue(dictionary []DictionaryEntry, input string) (string, error) {
+	normalized := normalizeForMatch(input)
+	for _, entry := range dictionary {
+		for _, candidate := range []string{entry.getValue(), entry.Name, entry.EnglishName, entry.Reading} {
+			if candidate != "" && normalizeForMatch(candidate) == normalized {
+				return entry.getValue(), nil
+			}
+		}
+	}
+	err := &UnknownNameError{Input: input}
+	if entries := completeDictionary(dictionary, input, 1); input != "" && len(entries) > 0 {
+		err.Suggestion = entries[0].Name
+	}
+	return "", err
+}
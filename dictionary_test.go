@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_completeDictionary(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "hiragana must be matched with katakana name",
+			input: "ゆのはな",
+			want:  "ユノハナ大渓谷",
+		},
+		{
+			name:  "kanji must be matched partially",
+			input: "海峡",
+			want:  "マサバ海峡大橋",
+		},
+		{
+			name:  "reading must be matched with kanji name",
+			input: "きんめだいび",
+			want:  "キンメダイ美術館",
+		},
+		{
+			name:  "english name must be matched case-insensitively",
+			input: "scorch",
+			want:  "ユノハナ大渓谷",
+		},
+		{
+			name:  "english name must be matched ignoring separators",
+			input: "mahimahi",
+			want:  "マヒマヒリゾート＆スパ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := completeDictionary(StageDictionary, tt.input, 25)
+			if len(got) == 0 || got[0].Name != tt.want {
+				t.Errorf("completeDictionary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolveDictionaryValue(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		want           string
+		wantSuggestion string
+		wantErr        bool
+	}{
+		{name: "AREA must be proceed as AREA", input: "AREA", want: "AREA"},
+		{name: "ガチエリア must be proceed as AREA", input: "ガチエリア", want: "AREA"},
+		{name: "がちあさり must be proceed as CLAM", input: "がちあさり", want: "CLAM"},
+		{name: "tower control must be proceed as LOFT", input: "tower control", want: "LOFT"},
+		{name: "a partial name must be suggested instead of guessed", input: "tower", wantSuggestion: "ガチヤグラ", wantErr: true},
+		{name: "an unrelated name must not be suggested", input: "ランダム", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDictionaryValue(RuleDictionary, tt.input)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Fatalf("resolveDictionaryValue() = %v, %v, want %v", got, err, tt.want)
+			}
+			if unknown, ok := err.(*UnknownNameError); ok && unknown.Suggestion != tt.wantSuggestion {
+				t.Errorf("Suggestion = %v, want %v", unknown.Suggestion, tt.wantSuggestion)
+			}
+		})
+	}
+}
@@ -1,26 +1,56 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 type DiscordBot struct {
+	Core                      *Bot
 	Session                   *discordgo.Session
 	AllowMessageContentIntent bool
-	registeredCommands        []*discordgo.ApplicationCommand
+	Registrar                 *CommandRegistrar
+	DeleteCommandsOnExit      bool
+	// Settings may be nil to use defaults in the config file
+	Settings *GuildSettingsStore
+	// Banners composes images of /table and banners attached if BannerImages is true
+	Banners      *BannerRenderer
+	BannerImages bool
 }
 
-func LaunchDiscordBot(botToken string, allowMessageContentIntent bool) (*DiscordBot, error) {
-	dg, err := discordgo.New("Bot " + botToken)
+type DiscordBotConfig struct {
+	Token                     string
+	AllowMessageContentIntent bool
+	// register commands to the guild instead of global if not empty
+	CommandGuildID       string
+	DeleteCommandsOnExit bool
+	Settings             *GuildSettingsStore
+	Banners              *BannerRenderer
+	BannerImages         bool
+}
+
+func LaunchDiscordBot(core *Bot, config DiscordBotConfig) (*DiscordBot, error) {
+	allowMessageContentIntent := config.AllowMessageContentIntent
+	dg, err := discordgo.New("Bot " + config.Token)
 	if err != nil {
 		return nil, err
 	}
-	dg.AddHandler(messageCreate)
-	dg.AddHandler(interactionCreate)
+	bot := DiscordBot{
+		Core:                      core,
+		Session:                   dg,
+		AllowMessageContentIntent: allowMessageContentIntent,
+		DeleteCommandsOnExit:      config.DeleteCommandsOnExit,
+		Settings:                  config.Settings,
+		Banners:                   config.Banners,
+		BannerImages:              config.BannerImages,
+	}
+	dg.AddHandler(bot.messageCreate)
+	dg.AddHandler(bot.interactionCreate)
 	dg.Identify.Intents |= discordgo.IntentsGuildMessages
 	if allowMessageContentIntent {
 		dg.Identify.Intents |= discordgo.IntentMessageContent
@@ -30,163 +60,167 @@ func LaunchDiscordBot(botToken string, allowMessageContentIntent bool) (*Discord
 		return nil, err
 	}
 
-	bot := DiscordBot{
-		Session:                   dg,
-		AllowMessageContentIntent: allowMessageContentIntent,
+	bot.Registrar = NewCommandRegistrar(dg, dg.State.User.ID, config.CommandGuildID)
+	err = bot.Registrar.Sync(slashCommands())
+	if err != nil {
+		metricsDiscordAPIErrorsTotal.WithLabelValues("sync_commands").Inc()
+		logger.Sugar().Errorf("Cannot sync commands: %v", err)
 	}
-	bot.setupSlashCommands()
 
 	return &bot, nil
 }
 
+// CheckGateway returns an error unless the gateway connection is ready
+func (bot *DiscordBot) CheckGateway() error {
+	bot.Session.RLock()
+	defer bot.Session.RUnlock()
+	if !bot.Session.DataReady {
+		return errors.New("discord gateway is not connected")
+	}
+	return nil
+}
+
+// CheckCommands returns an error unless slash commands are registered
+func (bot *DiscordBot) CheckCommands() error {
+	if bot.Registrar == nil || !bot.Registrar.IsRegistered(slashCommands()) {
+		return errors.New("slash commands are not registered")
+	}
+	return nil
+}
+
 func (bot *DiscordBot) CloseDiscordBot() {
-	for _, val := range bot.registeredCommands {
-		err := bot.Session.ApplicationCommandDelete(bot.Session.State.User.ID, "", val.ID)
-		if err == nil {
-			logger.Sugar().Infof("Deleted a command '%#v'", val.Name)
-		} else {
-			logger.Sugar().Errorf("Cannot delete command '%v': %v", val.Name, err)
-		}
+	if bot.DeleteCommandsOnExit {
+		bot.Registrar.DeleteAll()
 	}
 	bot.Session.Close()
 }
 
-func (bot *DiscordBot) setupSlashCommands() {
-	commands := []*discordgo.ApplicationCommand{
-		{
-			Name:        "regular",
-			Description: "Return a schedule for regular match",
-		},
-		{
-			Name:        "bankara",
-			Description: "Return a schedule for both Open and Challenge match",
-		},
-		{
-			Name:        "open",
-			Description: "Return a schedule for Open match",
-		},
-		{
-			Name:        "challenge",
-			Description: "Return a schedule for Challenge match",
-		},
-		{
-			Name:        "salmon",
-			Description: "Return a schedule for Salmon Run",
-		},
-		{
-			Name:        "x",
-			Description: "Return a schedule for X Match",
-		},
-		{
-			Name:        "rule",
-			Description: "Search both schedules from Open and Challenge match by rule name",
-			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Name:        "rule",
-					Description: "a rule name to search",
-					Type:        discordgo.ApplicationCommandOptionString,
-					Required:    true,
-					Choices: []*discordgo.ApplicationCommandOptionChoice{
-						{
-							Name: "turf-war",
-							NameLocalizations: map[discordgo.Locale]string{
-								discordgo.Japanese: "ナワバリバトル",
-							},
-							Value: "TURF_WAR",
-						},
-						{
-							Name: "area",
-							NameLocalizations: map[discordgo.Locale]string{
-								discordgo.Japanese: "ガチエリア",
-							},
-							Value: "AREA",
-						},
-						{
-							Name: "rainmarker",
-							NameLocalizations: map[discordgo.Locale]string{
-								discordgo.Japanese: "ガチホコバトル",
-							},
-							Value: "GOAL",
-						},
-						{
-							Name: "tower-control",
-							NameLocalizations: map[discordgo.Locale]string{
-								discordgo.Japanese: "ガチヤグラ",
-							},
-							Value: "LOFT",
-						},
-						{
-							Name: "clam-blitz",
-							NameLocalizations: map[discordgo.Locale]string{
-								discordgo.Japanese: "ガチアサリ",
-							},
-							Value: "CLAM",
-						},
-					},
-				},
+func createMessageEmbedFromResponseCard(card ResponseCard) *discordgo.MessageEmbed {
+	if card.NotFound {
+		return &discordgo.MessageEmbed{
+			Author: &discordgo.MessageEmbedAuthor{
+				Name: card.ModeName,
 			},
+			Description: card.Description(),
+		}
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: card.Title,
+		Author: &discordgo.MessageEmbedAuthor{
+			Name: card.ModeName,
 		},
+		Description: fmt.Sprintf("%s\n\n%s", discordTimeRange(card), strings.Join(card.Lines, "\n")),
+		Color:       card.Color,
 	}
-	bot.registeredCommands = make([]*discordgo.ApplicationCommand, len(commands))
-	for idx, val := range commands {
-		registered, err := bot.Session.ApplicationCommandCreate(bot.Session.State.User.ID, "", val)
-		if err == nil {
-			logger.Sugar().Infof("Created a command '%#v'", val.Name)
-		} else {
-			logger.Sugar().Errorf("Cannot create command '%#v': %#v", val.Name, err)
-		}
-		bot.registeredCommands[idx] = registered
+	if len(card.StageImages) > 0 {
+		embed.Image = &discordgo.MessageEmbedImage{URL: card.StageImages[0]}
 	}
+	if len(card.StageImages) > 1 {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: card.StageImages[1]}
+	}
+	return embed
 }
 
-func printWeaponsList(weapons []WeaponInfo) string {
-	return fmt.Sprintf("%s\n%s\n%s\n%s", weapons[0].Name, weapons[1].Name, weapons[2].Name, weapons[3].Name)
+// discordTimeRange uses timestamp markup to show times in the timezone of each reader with a countdown
+func discordTimeRange(card ResponseCard) string {
+	timeRange := fmt.Sprintf("<t:%d:F> - <t:%d:F>", card.StartTime.Unix(), card.EndTime.Unix())
+	if card.Question != "" && !card.Now.IsZero() {
+		answer := card.Answer(func(t time.Time) string {
+			return fmt.Sprintf("<t:%d:f>", t.Unix())
+		}, func(start bool) string {
+			if start {
+				return localize(card.Locale, "discord_starts", fmt.Sprintf("<t:%d:R>", card.StartTime.Unix()))
+			}
+			return localize(card.Locale, "discord_ends", fmt.Sprintf("<t:%d:R>", card.EndTime.Unix()))
+		})
+		return fmt.Sprintf("**%s**\n%s", answer, timeRange)
+	}
+	return fmt.Sprintf("%s\n%s", timeRange, discordCountdown(card))
 }
 
-func createMessageEmbedFromTimeSlotInfo(srs SearchResultSlot) *discordgo.MessageEmbed {
-	if srs.tsi == nil {
-		return &discordgo.MessageEmbed{
-			Author: &discordgo.MessageEmbedAuthor{
-				Name: srs.mode.getModeName(),
-			},
-			Description: "Not Found!",
-		}
+func discordCountdown(card ResponseCard) string {
+	if card.Now.Before(card.StartTime) {
+		return localize(card.Locale, "discord_starts", fmt.Sprintf("<t:%d:R>", card.StartTime.Unix()))
 	}
-	if srs.mode.getIdentifier() == "SALMON" {
-		return &discordgo.MessageEmbed{
-			Title: srs.tsi.Stage.Name,
-			Author: &discordgo.MessageEmbedAuthor{
-				Name: srs.mode.getModeName(),
-			},
-			Description: fmt.Sprintf("%d/%d %d時～%d/%d %d時\n\n%s",
-				srs.tsi.StartTime.Month(), srs.tsi.StartTime.Day(), srs.tsi.StartTime.Hour(),
-				srs.tsi.EndTime.Month(), srs.tsi.EndTime.Day(), srs.tsi.EndTime.Hour(),
-				printWeaponsList(srs.tsi.Weapons)),
-			Color: srs.mode.getColor(),
+	return localize(card.Locale, "discord_ends", fmt.Sprintf("<t:%d:R>", card.EndTime.Unix()))
+}
+
+// createOverviewEmbed packs cards into fields of a single embed
+func createOverviewEmbed(cards []ResponseCard) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{}
+	for _, card := range cards {
+		if card.NotFound {
+			continue
 		}
-	} else {
-		return &discordgo.MessageEmbed{
-			Title: srs.tsi.Rule.Name,
-			Author: &discordgo.MessageEmbedAuthor{
-				Name: srs.mode.getModeName(),
-			},
-			Description: fmt.Sprintf("%d/%d %d時～%d/%d %d時\n\n%s\n%s",
-				srs.tsi.StartTime.Month(), srs.tsi.StartTime.Day(), srs.tsi.StartTime.Hour(),
-				srs.tsi.EndTime.Month(), srs.tsi.EndTime.Day(), srs.tsi.EndTime.Hour(),
-				srs.tsi.Stages[0].Name, srs.tsi.Stages[1].Name),
-			Color: srs.mode.getColor(),
+		if embed.Color == 0 {
+			embed.Color = card.Color
 		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s: %s", card.ModeName, card.Title),
+			Value: fmt.Sprintf("%s\n%s", strings.Join(card.Lines, " / "), discordCountdown(card)),
+		})
 	}
+	return embed
 }
 
-func createStageInfoEmbeds(sr SearchResult) []*discordgo.MessageEmbed {
+func createStageInfoEmbeds(cards []ResponseCard) []*discordgo.MessageEmbed {
 	var embeds []*discordgo.MessageEmbed
-	for _, slot := range sr.Slots {
-		embeds = append(embeds, createMessageEmbedFromTimeSlotInfo(slot))
+	for _, card := range cards {
+		embeds = append(embeds, createMessageEmbedFromResponseCard(card))
 	}
 	return embeds
 }
 
+// attachBanners replaces images of the embeds by composed banners; embeds keep the original images on failures
+func (bot *DiscordBot) attachBanners(cards []ResponseCard, embeds []*discordgo.MessageEmbed) []*discordgo.File {
+	if bot.Banners == nil || !bot.BannerImages {
+		return nil
+	}
+	var files []*discordgo.File
+	for idx, card := range cards {
+		if card.NotFound {
+			continue
+		}
+		data, err := bot.Banners.Render(card)
+		if err != nil {
+			logger.Sugar().Warnf("Cannot render banner of %s: %v", card.Key, err)
+			continue
+		}
+		name := fmt.Sprintf("banner-%s.png", card.Key)
+		embeds[idx].Image = &discordgo.MessageEmbedImage{URL: "attachment://" + name}
+		embeds[idx].Thumbnail = nil
+		files = append(files, &discordgo.File{
+			Name:        name,
+			ContentType: "image/png",
+			Reader:      bytes.NewReader(data),
+		})
+	}
+	return files
+}
+
+// respondTableImage defers the response since fetching images of all stages takes a while
+func (bot *DiscordBot) respondTableImage(s *discordgo.Session, i *discordgo.InteractionCreate, table ScheduleTable) error {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		return err
+	}
+	data, err := bot.Banners.RenderTable(table)
+	if err != nil {
+		logger.Sugar().Warnf("Cannot render table: %v", err)
+		content := "```\n" + table.Text(tableTextLength) + "\n```"
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return err
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Files: []*discordgo.File{
+			{Name: "table.png", ContentType: "image/png", Reader: bytes.NewReader(data)},
+		},
+	})
+	return err
+}
+
 func isMentioned(user *discordgo.User, mentions []*discordgo.User, messageContent string) bool {
 	for _, mention := range mentions {
 		if mention.ID == user.ID {
@@ -197,7 +231,14 @@ func isMentioned(user *discordgo.User, mentions []*discordgo.User, messageConten
 	return false
 }
 
-func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+func (bot *DiscordBot) guildSettings(guildID string) GuildSettings {
+	if bot.Settings == nil {
+		return getConfig().Guild(guildID)
+	}
+	return bot.Settings.Get(guildID)
+}
+
+func (bot *DiscordBot) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 	if m.Author.Bot {
 		return
 	}
@@ -206,97 +247,204 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		return
 	}
 
-	// remove mention syntax
-	regex := regexp.MustCompile(` *<@&?\d+?> *`)
-	input = regex.ReplaceAllString(input, "")
-	// remove spaces
-	input = strings.ReplaceAll(input, " ", "")
-
-	// parse
-	query := Parse(input)
-
-	// ignore when no match
-	if query.OriginalText == "" {
+	settings := bot.guildSettings(m.GuildID)
+	listenMode := settings.ListenMode(m.ChannelID)
+	if listenMode == ListenIgnore {
+		return
+	}
+	input, prefixed := settings.TrimPrefix(input)
+	mentioned := isMentioned(s.State.User, m.Mentions, input) || prefixed
+	if !mentioned && listenMode == ListenMention {
 		return
 	}
 
-	// query
-	scheduleStore.MaybeRefresh()
-	sr := scheduleStore.Search(query)
+	resp := bot.Core.Handle(BotRequest{
+		Text:        input,
+		Mentioned:   mentioned,
+		Transport:   "discord",
+		DefaultMode: settings.DefaultMode,
+		Locale:      settings.Locale,
+	})
+	if resp.Ignored {
+		return
+	}
 
 	// reply
 	var err error
-	if sr.Found {
-		_, err = s.ChannelMessageSendEmbedsReply(m.ChannelID, createStageInfoEmbeds(sr), m.Reference())
+	if resp.Compact {
+		_, err = s.ChannelMessageSendEmbedReply(m.ChannelID, createOverviewEmbed(resp.Cards), m.Reference())
+	} else if len(resp.Cards) > 0 {
+		embeds := createStageInfoEmbeds(resp.Cards)
+		_, err = s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Embeds:    embeds,
+			Files:     bot.attachBanners(resp.Cards, embeds),
+			Reference: m.Reference(),
+		})
 	} else {
-		if isMentioned(s.State.User, m.Mentions, input) {
-			_, err = s.ChannelMessageSendReply(m.ChannelID, "Not Found!", m.Reference())
-		}
+		_, err = s.ChannelMessageSendReply(m.ChannelID, resp.Text, m.Reference())
 	}
 	if err != nil {
+		metricsDiscordAPIErrorsTotal.WithLabelValues("channel_message_send").Inc()
 		logger.Sugar().Error(err)
 	}
 }
 
-func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	commandName2mode := map[string]string{
-		"regular":   "REGULAR",
-		"bankara":   "BANKARA",
-		"open":      "OPEN",
-		"challenge": "CHALLENGE",
-		"salmon":    "SALMON",
-		"x":         "X",
-	}
-	commandName := i.ApplicationCommandData().Name
+func getInteractionOptions(i *discordgo.InteractionCreate) map[string]string {
+	options := map[string]string{}
+	for _, opt := range i.ApplicationCommandData().Options {
+		options[opt.Name] = fmt.Sprint(opt.Value)
+	}
+	return options
+}
 
-	var query *SearchQuery
-	modeName, found := commandName2mode[commandName]
-	if found {
-		query = &SearchQuery{Mode: getMode(modeName)}
+func (bot *DiscordBot) interactionAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Focused {
+			input, _ := opt.Value.(string)
+			for _, entry := range bot.Core.Complete(opt.Name, input) {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  entry.getLabel(),
+					Value: entry.getValue(),
+				})
+			}
+			break
+		}
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		metricsDiscordAPIErrorsTotal.WithLabelValues("interaction_respond").Inc()
+		logger.Sugar().Error(err)
 	}
+}
 
-	if commandName == "rule" {
-		opts := i.ApplicationCommandData().Options
-		if len(opts) > 0 {
-			query = &SearchQuery{Mode: getMode("BYRULE"), Rule: opts[0].Value.(string)}
+// interactionLocale prefers the locale of the guild settings to the locale of the user
+func (bot *DiscordBot) interactionLocale(i *discordgo.InteractionCreate) string {
+	if i.GuildID != "" {
+		if locale := bot.guildSettings(i.GuildID).Locale; locale != "" {
+			return locale
 		}
 	}
+	return normalizeLocale(string(i.Locale))
+}
 
-	// check command structure is valid
-	if query == nil {
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "Invalid command!",
-			},
-		})
+// interactionConfig shows or changes settings of the guild
+func (bot *DiscordBot) interactionConfig(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var content string
+	locale := bot.interactionLocale(i)
+	options := getInteractionOptions(i)
+	if _, found := options["channel_mode"]; found && options["channel"] == "" {
+		options["channel"] = i.ChannelID
+	}
+	switch {
+	case i.GuildID == "" || bot.Settings == nil:
+		content = localize(locale, "settings_dm")
+	case len(options) > 0 && (i.Member == nil || i.Member.Permissions&discordgo.PermissionManageServer == 0):
+		// permissions of commands may be overridden by servers; check it again
+		content = localize(locale, "settings_permission")
+	case options["reset"] == "true":
+		err := bot.Settings.Reset(i.GuildID)
 		if err != nil {
-			logger.Sugar().Error(err)
+			logger.Sugar().Errorf("Cannot reset guild settings: %v", err)
+			content = localize(locale, "settings_save_error")
+		} else {
+			content = localize(locale, "settings_reset") + "\n" + bot.Settings.Get(i.GuildID).Describe()
+		}
+	default:
+		settings, err := bot.Settings.Get(i.GuildID).ApplyOptions(options)
+		if err != nil {
+			content = err.Error()
+			break
+		}
+		if len(options) > 0 {
+			err = bot.Settings.Set(i.GuildID, settings)
+			if err != nil {
+				logger.Sugar().Errorf("Cannot save guild settings: %v", err)
+				content = localize(locale, "settings_save_error")
+				break
+			}
 		}
+		content = settings.Describe()
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		metricsDiscordAPIErrorsTotal.WithLabelValues("interaction_respond").Inc()
+		logger.Sugar().Error(err)
+	}
+}
+
+func (bot *DiscordBot) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		bot.interactionAutocomplete(s, i)
+		return
+	default:
+		return
+	}
+	if i.ApplicationCommandData().Name == "config" {
+		bot.interactionConfig(s, i)
+		return
 	}
-	// if valid, query to schedule store
-	scheduleStore.MaybeRefresh()
-	sr := scheduleStore.Search(query)
+
+	resp := bot.Core.Handle(BotRequest{
+		Command:   i.ApplicationCommandData().Name,
+		Options:   getInteractionOptions(i),
+		Transport: "discord",
+		Locale:    bot.interactionLocale(i),
+	})
 
 	// reply
 	var err error
-	if sr.Found {
-		embeds := createStageInfoEmbeds(sr)
+	if resp.Compact {
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{createOverviewEmbed(resp.Cards)},
+			},
+		})
+	} else if resp.Table != nil && getInteractionOptions(i)["format"] == "image" && bot.Banners != nil {
+		err = bot.respondTableImage(s, i, *resp.Table)
+	} else if len(resp.Cards) > 0 && bot.Banners != nil && bot.BannerImages {
+		// composing banners may take longer than the deadline of interactions
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		})
+		if err == nil {
+			embeds := createStageInfoEmbeds(resp.Cards)
+			_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Embeds: &embeds,
+				Files:  bot.attachBanners(resp.Cards, embeds),
+			})
+		}
+	} else if len(resp.Cards) > 0 {
 		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Embeds: embeds,
+				Embeds: createStageInfoEmbeds(resp.Cards),
 			},
 		})
 	} else {
 		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "Not Found!",
+				Content: resp.Text,
 			},
 		})
 	}
 	if err != nil {
+		metricsDiscordAPIErrorsTotal.WithLabelValues("interaction_respond").Inc()
 		logger.Sugar().Error(err)
 	}
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ScheduleEvent is a change found between successive schedule refreshes
+type ScheduleEvent struct {
+	ID string `json:"id"`
+	// Kind is one of "rotation", "bigrun", "fest" and "event"
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Published time.Time `json:"published"`
+	Slot      SlotView  `json:"slot"`
+	Images    []string  `json:"images,omitempty"`
+}
+
+type EventLog struct {
+	sync.RWMutex
+	cache  *FileCache
+	events []ScheduleEvent
+	limit  int
+}
+
+// battle modes watched for newly published rotations
+var eventLogModes = []string{"REGULAR", "OPEN", "CHALLENGE", "X"}
+
+func NewEventLog(workdir string, cacheName string, limit int) *EventLog {
+	el := &EventLog{
+		cache: NewFileCache(workdir, cacheName),
+		limit: limit,
+	}
+	// the log never expires
+	if restored := MaybeGetFromFileCache[[]ScheduleEvent](el.cache, time.Hour*24*365*100); restored != nil {
+		el.events = *restored
+	}
+	return el
+}
+
+func (el *EventLog) Append(events []ScheduleEvent) {
+	if len(events) == 0 {
+		return
+	}
+	el.Lock()
+	defer el.Unlock()
+	el.events = append(el.events, events...)
+	if len(el.events) > el.limit {
+		el.events = el.events[len(el.events)-el.limit:]
+	}
+	// copy to avoid sharing the backing array with the cache
+	persisted := make([]ScheduleEvent, len(el.events))
+	copy(persisted, el.events)
+	_, err := el.cache.Put(&persisted)
+	if err != nil {
+		logger.Sugar().Errorf("Cannot persist event log: %v", err)
+	}
+	logger.Sugar().Infof("Appended %d schedule events", len(events))
+}
+
+// Events returns events in the newest first order
+func (el *EventLog) Events() []ScheduleEvent {
+	el.RLock()
+	defer el.RUnlock()
+	events := make([]ScheduleEvent, len(el.events))
+	for idx, event := range el.events {
+		events[len(el.events)-idx-1] = event
+	}
+	return events
+}
+
+func slotKey(mode string, tsinfo *TimeSlotInfo) string {
+	return fmt.Sprintf("%s-%s", mode, tsinfo.StartTime.UTC().Format("20060102T150405Z"))
+}
+
+func newScheduleEvent(kind string, title string, srs SearchResultSlot, timeStamp time.Time) ScheduleEvent {
+	card := createResponseCard(srs)
+	event := ScheduleEvent{
+		ID:        kind + "-" + slotKey(slotModeKey(srs), srs.tsi),
+		Kind:      kind,
+		Title:     fmt.Sprintf("%s: %s %s", title, card.Title, card.TimeRange()),
+		Published: timeStamp,
+		Slot:      createSlotView(srs),
+	}
+	if srs.mode.getIdentifier() == "SALMON" {
+		if srs.tsi.Stage.Image != "" {
+			event.Images = append(event.Images, srs.tsi.Stage.Image)
+		}
+	} else {
+		for _, stage := range srs.tsi.Stages {
+			if stage.Image != "" {
+				event.Images = append(event.Images, stage.Image)
+			}
+		}
+	}
+	return event
+}
+
+func newSlots(mode string, previous []TimeSlotInfo, current []TimeSlotInfo) []*TimeSlotInfo {
+	known := map[string]bool{}
+	for idx := range previous {
+		known[slotKey(mode, &previous[idx])] = true
+	}
+	var slots []*TimeSlotInfo
+	for idx := range current {
+		if !known[slotKey(mode, &current[idx])] {
+			slots = append(slots, &current[idx])
+		}
+	}
+	return slots
+}
+
+// diffScheduleInfo finds newly published rotations, fests and event matches
+func diffScheduleInfo(previous *AllScheduleInfo, current *AllScheduleInfo, timeStamp time.Time) []ScheduleEvent {
+	if previous == nil || current == nil {
+		return nil
+	}
+	var events []ScheduleEvent
+	for _, mode := range eventLogModes {
+		for _, tsinfo := range newSlots(mode, previous.getTimeSlotInfoByMode(getMode(mode)), current.getTimeSlotInfoByMode(getMode(mode))) {
+			if tsinfo.IsFest {
+				continue
+			}
+			srs := SearchResultSlot{getMode(mode), tsinfo}
+			events = append(events, newScheduleEvent("rotation", getMode(mode).getModeName(), srs, timeStamp))
+		}
+	}
+	for _, tsinfo := range newSlots("EVENT", previous.Event, current.Event) {
+		srs := SearchResultSlot{getMode("EVENT"), tsinfo}
+		events = append(events, newScheduleEvent("event", "イベントマッチ開催", srs, timeStamp))
+	}
+	// announce a fest only once; it consists of many slots
+	if fests := newSlots("FEST", previous.Fest, current.Fest); len(fests) > 0 && len(previous.Fest) == 0 {
+		srs := SearchResultSlot{getMode("FEST"), fests[0]}
+		events = append(events, newScheduleEvent("fest", "フェス開催", srs, timeStamp))
+	}
+	return events
+}
+
+// diffSalmonInfo finds newly published Salmon Run rotations and Big Runs
+func diffSalmonInfo(previous *[]TimeSlotInfo, current *[]TimeSlotInfo, timeStamp time.Time) []ScheduleEvent {
+	if previous == nil || current == nil {
+		return nil
+	}
+	var events []ScheduleEvent
+	for _, tsinfo := range newSlots("SALMON", *previous, *current) {
+		if tsinfo.IsBigRun {
+			events = append(events, newScheduleEvent("bigrun", "ビッグラン開催", SearchResultSlot{getMode("BIGRUN"), tsinfo}, timeStamp))
+		} else {
+			events = append(events, newScheduleEvent("rotation", getMode("SALMON").getModeName(), SearchResultSlot{getMode("SALMON"), tsinfo}, timeStamp))
+		}
+	}
+	return events
+}
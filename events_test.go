@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffScheduleInfo(t *testing.T) {
+	previous := newTestStore()
+	current := newTestStore()
+	// a rotation is published at the end of X Match
+	x := current.info.XMatch
+	last := x[len(x)-1]
+	published := last
+	published.StartTime = last.EndTime
+	published.EndTime = last.EndTime.Add(time.Hour * 2)
+	published.Rule = RuleInfo{Key: "LOFT", Name: "ガチヤグラ"}
+	published.Stages = []StageInfo{{Name: "ユノハナ大渓谷", Image: "https://example.com/1.png"}, {Name: "ゴンズイ地区", Image: "https://example.com/2.png"}}
+	current.info.XMatch = append(append([]TimeSlotInfo{}, x[1:]...), published)
+	// an event match is announced
+	current.info.Event = []TimeSlotInfo{{
+		StartTime: testNow.Add(time.Hour * 24),
+		EndTime:   testNow.Add(time.Hour * 26),
+		Rule:      RuleInfo{Key: "AREA", Name: "ガチエリア"},
+		Stages:    []StageInfo{{Name: "ヤガラ市場"}, {Name: "マテガイ放水路"}},
+		Event:     &EventInfo{ID: "1", Name: "ハイカラ乱打戦"},
+	}}
+
+	events := diffScheduleInfo(previous.info, current.info, testNow)
+	if len(events) != 2 {
+		t.Fatalf("events = %#v", events)
+	}
+	if events[0].Kind != "rotation" || events[0].Slot.Mode != "X" || events[0].Title != "Xマッチ: ガチヤグラ 3/2 17時～3/2 19時" {
+		t.Errorf("events[0] = %#v", events[0])
+	}
+	if len(events[0].Images) != 2 || events[0].Images[0] != "https://example.com/1.png" {
+		t.Errorf("events[0].Images = %v", events[0].Images)
+	}
+	if events[1].Kind != "event" || !strings.Contains(events[1].Title, "ハイカラ乱打戦") {
+		t.Errorf("events[1] = %#v", events[1])
+	}
+
+	if events := diffScheduleInfo(nil, current.info, testNow); len(events) != 0 {
+		t.Errorf("events without previous schedules = %#v", events)
+	}
+}
+
+func TestDiffSalmonInfo(t *testing.T) {
+	store := newTestStore()
+	previous := (*store.salmonInfo)[:2]
+	events := diffSalmonInfo(&previous, store.salmonInfo, testNow)
+	if len(events) != 1 || events[0].Kind != "bigrun" || events[0].Slot.Mode != "BIGRUN" {
+		t.Fatalf("events = %#v", events)
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	dir := t.TempDir()
+	el := NewEventLog(dir, "events", 2)
+	for idx := 0; idx < 3; idx++ {
+		el.Append([]ScheduleEvent{{ID: string(rune('a' + idx)), Published: testNow}})
+	}
+	if events := el.Events(); len(events) != 2 || events[0].ID != "c" || events[1].ID != "b" {
+		t.Errorf("Events() = %#v", events)
+	}
+	// restored from the file
+	restored := NewEventLog(dir, "events", 2)
+	if events := restored.Events(); len(events) != 2 || events[0].ID != "c" {
+		t.Errorf("restored Events() = %#v", events)
+	}
+}
+
+func TestGenerateAtom(t *testing.T) {
+	store := newTestStore()
+	events := diffSalmonInfo(&[]TimeSlotInfo{}, store.salmonInfo, testNow)
+	body, err := GenerateAtom(events, "https://example.com/v1/feed.atom", testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed atomFeed
+	err = xml.Unmarshal(body, &feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Entries) != 3 || feed.Entries[2].ID != "tag:ikabot3,2022:bigrun-BIGRUN-20230304T160000Z" {
+		t.Errorf("entries = %#v", feed.Entries)
+	}
+	if !strings.Contains(feed.Entries[2].Content.Body, "<li>スメーシーワールド</li>") {
+		t.Errorf("content = %v", feed.Entries[2].Content.Body)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// debugFlag at the end of keywords asks for an Explanation instead of schedules
+const debugFlag = "?debug"
+
+// trimDebugFlag removes debugFlag from the text and reports whether it is given
+func trimDebugFlag(text string) (string, bool) {
+	trimmed := strings.TrimSpace(norm.NFKC.String(text))
+	if !strings.HasSuffix(strings.ToLower(trimmed), debugFlag) {
+		return text, false
+	}
+	return strings.TrimSpace(trimmed[:len(trimmed)-len(debugFlag)]), true
+}
+
+// Explanation tells how keywords are parsed and searched; replied by /explain and keywords ending with ?debug
+type Explanation struct {
+	Input string
+	// Parser is japanese or english
+	Parser string
+	// Normalized are clauses given to the grammar of Parse; empty for English
+	Normalized []string
+	// Suggestion is given if no keywords matched
+	Suggestion string
+	// Error is why keywords were rejected such as too many queries
+	Error string
+	// Results are in the order of parsed queries
+	Results []SearchResult
+	Sources []DataSource
+	Now     time.Time
+}
+
+func describeQuery(query *SearchQuery) string {
+	fields := []string{fmt.Sprintf("%q", query.OriginalText)}
+	for _, field := range []struct{ name, value string }{
+		{"mode", query.Mode.getIdentifier()},
+		{"rule", query.Rule},
+		{"relative", query.RelativeIndex},
+		{"time", query.TimeIndex},
+		{"question", query.Question},
+		{"language", query.Language},
+	} {
+		if field.value != "" {
+			fields = append(fields, field.name+"="+field.value)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+func describeSlot(srs SearchResultSlot) string {
+	if srs.tsi == nil {
+		return slotModeKey(srs) + " not found"
+	}
+	window := fmt.Sprintf("%s-%s", srs.tsi.StartTime.Format("01/02 15:04"), srs.tsi.EndTime.Format("01/02 15:04"))
+	if srs.tsi.Rule.Key == "" {
+		var weapons []string
+		for _, weapon := range srs.tsi.Weapons {
+			weapons = append(weapons, weapon.Name)
+		}
+		return fmt.Sprintf("%s %s %s (%s)", slotModeKey(srs), window, srs.tsi.Stage.Name, strings.Join(weapons, ", "))
+	}
+	var stages []string
+	for _, stage := range srs.tsi.Stages {
+		stages = append(stages, stage.Name)
+	}
+	return fmt.Sprintf("%s %s %s (%s)", slotModeKey(srs), window, srs.tsi.Rule.Key, strings.Join(stages, ", "))
+}
+
+// Text renders the explanation in plain lines for code blocks
+func (e Explanation) Text() string {
+	lines := []string{
+		"input: " + e.Input,
+		"parser: " + e.Parser,
+	}
+	if len(e.Normalized) > 0 {
+		lines = append(lines, "normalized: "+strings.Join(e.Normalized, " / "))
+	}
+	lines = append(lines, "now: "+e.Now.Format(time.RFC3339))
+	if len(e.Results) == 0 && e.Error != "" {
+		lines = append(lines, "query: "+e.Error)
+	} else if len(e.Results) == 0 {
+		lines = append(lines, "query: no keywords matched")
+		if e.Suggestion != "" {
+			lines = append(lines, "suggestion: "+e.Suggestion)
+		}
+	}
+	for idx, sr := range e.Results {
+		lines = append(lines,
+			fmt.Sprintf("query %d: %s", idx+1, describeQuery(sr.Query)),
+			fmt.Sprintf("  lookup: %s in %s (%s)", sr.Trace.Lookup, sr.Trace.Source, strings.Join(sr.Trace.Modes, ", ")),
+			"  filters: "+strings.Join(sr.Trace.Filters, ", "),
+		)
+		for _, slot := range sr.Slots {
+			lines = append(lines, "  slot: "+describeSlot(slot))
+		}
+	}
+	for _, source := range e.Sources {
+		if source.UpdatedAt.IsZero() {
+			lines = append(lines, fmt.Sprintf("source %s: %s (not cached)", source.Name, source.URL))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("source %s: %s (updated %s, %s ago)",
+			source.Name, source.URL, source.UpdatedAt.Format(time.RFC3339), e.Now.Sub(source.UpdatedAt).Round(time.Second)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_trimDebugFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantFound bool
+	}{
+		{name: "?debug must be removed", args: "次のガチマ?debug", want: "次のガチマ", wantFound: true},
+		{name: "full-width question marks and spaces must be allowed", args: "次のガチマ ？DEBUG", want: "次のガチマ", wantFound: true},
+		{name: "text without the flag must be kept", args: "次のガチマ?", want: "次のガチマ?", wantFound: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := trimDebugFlag(tt.args)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("trimDebugFlag() = %v, %v, want %v, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestBot_Handle_Explain(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       BotRequest
+		wantLines []string
+	}{
+		{
+			name: "?debug must explain the time lookup",
+			req:  BotRequest{Text: "次のガチマ?debug", Mentioned: true},
+			wantLines: []string{
+				"normalized: 次のガチマ",
+				`query 1: "次のガチマ" mode=CHALLENGE relative=1`,
+				"  lookup: time in schedule (CHALLENGE)",
+				"  filters: relative=1, window=13:00-15:00 JST",
+				"  slot: CHALLENGE 03/02 13:00-03/02 15:00 LOFT (ナメロウ金属, クサヤ温泉)",
+				"source schedule: https://example.com/api/schedule (updated 2023-03-02T12:25:00+09:00, 5m0s ago)",
+			},
+		},
+		{
+			name: "/explain must explain every clause",
+			req:  BotRequest{Command: "explain", Options: map[string]string{"query": "ガチマと次のシャケ"}},
+			wantLines: []string{
+				"normalized: ガチマ / 次のシャケ",
+				"  lookup: salmon in salmon (SALMON)",
+				"  filters: index=1 of 3 slots",
+			},
+		},
+		{
+			name: "English keywords must be explained without normalization",
+			req:  BotRequest{Text: "next splat zones?debug", Mentioned: true},
+			wantLines: []string{
+				"parser: english",
+				"  filters: rule=AREA, skip=1",
+				"  slot: OPEN not found",
+			},
+		},
+		{
+			name: "unknown keywords must be explained with a suggestion",
+			req:  BotRequest{Text: "ガチアリ?debug", Mentioned: true},
+			wantLines: []string{
+				"query: no keywords matched",
+				"suggestion: ガチアサリ",
+			},
+		},
+		{
+			name: "too many keywords must be explained",
+			req:  BotRequest{Text: "エリアとヤグラとホコとアサリ?debug", Mentioned: true},
+			wantLines: []string{
+				"normalized: エリア / ヤグラ / ホコ / アサリ",
+				"query: too many queries",
+			},
+		},
+	}
+	bot := NewBot(newTestStore())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := bot.Handle(tt.req)
+			if resp.Explanation == nil {
+				t.Fatalf("Explanation must be given: %v", resp)
+			}
+			lines := strings.Split(resp.Text, "\n")
+			for _, want := range tt.wantLines {
+				found := false
+				for _, line := range lines {
+					if line == want {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Text() must have %q:\n%s", want, resp.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestBot_Handle_ExplainWithoutMention(t *testing.T) {
+	bot := NewBot(newTestStore())
+	for _, text := range []string{"hello ?debug", "次のガチマ?debug"} {
+		if resp := bot.Handle(BotRequest{Text: text}); !resp.Ignored || resp.Explanation != nil {
+			t.Errorf("?debug without mentions must be ignored: %q got %v", text, resp)
+		}
+	}
+}
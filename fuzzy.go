@@ -0,0 +1,163 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// keywordVocabulary lists katakana keywords of the grammar in Parse
+var keywordVocabulary = []string{
+	"ガチマッチ", "ガチマ", "ガチ", "リグマ", "バカマ", "マッチ",
+	"レギュラー", "リーグ", "バンカラ", "オープン", "チャレンジ", "エックス",
+	"ナワバリバトル", "ナワバリ", "エリア", "ホコバトル", "ホコ", "ヤグラ", "アサリ",
+	"シャケ", "サーモンラン", "サーモン",
+}
+
+// suggestionVocabulary lists whole keywords suggested for misspelled inputs
+var suggestionVocabulary = []string{
+	"ガチマッチ", "リグマ", "バカマ", "レギュラーマッチ", "バンカラマッチ", "オープンマッチ", "チャレンジマッチ", "エックスマッチ",
+	"ナワバリバトル", "ガチエリア", "ガチホコ", "ガチヤグラ", "ガチアサリ", "エリア", "ホコ", "ヤグラ", "アサリ",
+	"サーモンラン", "シャケ",
+}
+
+var smallKana = map[rune]rune{
+	'ァ': 'ア', 'ィ': 'イ', 'ゥ': 'ウ', 'ェ': 'エ', 'ォ': 'オ',
+	'ッ': 'ツ', 'ャ': 'ヤ', 'ュ': 'ユ', 'ョ': 'ヨ', 'ヮ': 'ワ',
+}
+
+// looseKana drops long vowels and enlarges small kana to tolerate typos such as ヤグラー and チヤレンジ
+func looseKana(input string) string {
+	return strings.Map(func(r rune) rune {
+		if r == 'ー' {
+			return -1
+		}
+		if large, found := smallKana[r]; found {
+			return large
+		}
+		return r
+	}, input)
+}
+
+var (
+	hiraganaRun   = regexp.MustCompile(`[ぁ-ゖー]+`)
+	katakanaRun   = regexp.MustCompile(`[ァ-ヺー]+`)
+	looseKeywords = func() map[string]string {
+		keywords := map[string]string{}
+		for _, keyword := range keywordVocabulary {
+			keywords[looseKana(keyword)] = keyword
+		}
+		return keywords
+	}()
+)
+
+// foldKeywords converts hiragana into katakana except particles, then replaces loosely matched katakana with keywords
+func foldKeywords(input string) string {
+	input = hiraganaRun.ReplaceAllStringFunc(input, func(run string) string {
+		// keep particles such as 次の and Xは
+		var particle string
+		if first, size := utf8.DecodeRuneInString(run); first == 'の' || first == 'は' {
+			particle, run = string(first), run[size:]
+		}
+		// keep と as a separator of clauses such as エリアとヤグラ unless it is in keywords such as なわばりばとる
+		if strings.ContainsRune(run, 'と') {
+			return particle + canonicalizeKana(toKatakana(run), run)
+		}
+		return particle + toKatakana(run)
+	})
+	return katakanaRun.ReplaceAllStringFunc(input, canonicalizeKeywords)
+}
+
+// canonicalizeKeywords splits a katakana run into the longest keywords from the beginning
+func canonicalizeKeywords(run string) string {
+	return canonicalizeKana(run, run)
+}
+
+// canonicalizeKana is canonicalizeKeywords which keeps と of original, the run before converted into katakana, out of keywords
+func canonicalizeKana(run string, original string) string {
+	runes, originalRunes := []rune(run), []rune(original)
+	var builder strings.Builder
+	for i := 0; i < len(runes); {
+		matched := false
+		for j := len(runes); j > i; j-- {
+			if keyword, found := looseKeywords[looseKana(string(runes[i:j]))]; found {
+				builder.WriteString(keyword)
+				i = j
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			if originalRunes[i] == 'と' {
+				builder.WriteRune('と')
+			} else {
+				builder.WriteRune(runes[i])
+			}
+			i++
+		}
+	}
+	return builder.String()
+}
+
+// editDistance is the Levenshtein distance between runes of a and b
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if d := current[j-1] + 1; d < current[j] {
+				current[j] = d
+			}
+			if d := previous[j-1] + cost; d < current[j] {
+				current[j] = d
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+var relativePrefix = regexp.MustCompile(`^(次の|前の)*`)
+
+// suggestKeyword returns a keyword close to the normalized input if only one is the closest; empty otherwise
+func suggestKeyword(input string) string {
+	input, _ = trimQuestion(input)
+	input = foldKeywords(input)
+	prefix := relativePrefix.FindString(input)
+	body := looseKana(strings.TrimPrefix(input, prefix))
+	length := utf8.RuneCountInString(body)
+	if length < 2 {
+		return ""
+	}
+	best, bestDistance, ambiguous := "", length, false
+	for _, candidate := range suggestionVocabulary {
+		distance := editDistance(body, looseKana(candidate))
+		// tolerate one typo in short keywords and two in long ones
+		limit := 1
+		if utf8.RuneCountInString(candidate) > 4 {
+			limit = 2
+		}
+		if distance == 0 || distance > limit || distance*2 >= length {
+			continue
+		}
+		if distance < bestDistance {
+			best, bestDistance, ambiguous = candidate, distance, false
+		} else if distance == bestDistance {
+			ambiguous = true
+		}
+	}
+	if best == "" || ambiguous {
+		return ""
+	}
+	return prefix + best
+}
@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func Test_editDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{name: "same strings must be 0", a: "ガチアサリ", b: "ガチアサリ", want: 0},
+		{name: "a missing character must be 1", a: "ガチアリ", b: "ガチアサリ", want: 1},
+		{name: "a replaced character must be 1", a: "ガチヤクラ", b: "ガチヤグラ", want: 1},
+		{name: "empty string must be the length", a: "", b: "ホコ", want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := editDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("editDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_foldKeywords(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{name: "hiragana must be katakana", args: "ちゃれんじ", want: "チャレンジ"},
+		{name: "particles must be kept", args: "次のがちま", want: "次のガチマ"},
+		{name: "long vowels must be tolerated", args: "ヤグラー", want: "ヤグラ"},
+		{name: "small kana must be tolerated", args: "チヤレンジマツチ", want: "チャレンジマッチ"},
+		{name: "missing long vowels must be tolerated", args: "サモンラン", want: "サーモンラン"},
+		{name: "unknown words must be kept", args: "ランダム", want: "ランダム"},
+		{name: "separators must be kept", args: "えりあとやぐら", want: "エリアとヤグラ"},
+		{name: "separators before other words must be kept", args: "エリアとこんにちは", want: "エリアとコンニチハ"},
+		{name: "と in keywords must be folded", args: "なわばりばとる", want: "ナワバリバトル"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := foldKeywords(tt.args); got != tt.want {
+				t.Errorf("foldKeywords() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_suggestKeyword(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{name: "ガチアリ must be ガチアサリ", args: "ガチアリ", want: "ガチアサリ"},
+		{name: "relative prefix must be kept", args: "次のがちやくら", want: "次のガチヤグラ"},
+		{name: "questions must be ignored", args: "ガチアリいつ？", want: "ガチアサリ"},
+		{name: "ambiguous input must not be suggested", args: "リカマ", want: ""},
+		{name: "unrelated text must not be suggested", args: "こんにちは", want: ""},
+		{name: "short text must not be suggested", args: "ホ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggestKeyword(tt.args); got != tt.want {
+				t.Errorf("suggestKeyword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GuildSettings changes behavior of the bot per Discord guild
+type GuildSettings struct {
+	// MentionOnly ignores keyword inputs without mentions even if Message Content Intent is allowed
+	MentionOnly bool `yaml:"mention_only" json:"mention_only"`
+	// DefaultMode is applied to keywords without modes such as 次の
+	DefaultMode string `yaml:"default_mode" json:"default_mode,omitempty"`
+	// Locale is either ja or en; empty means ja
+	Locale string `yaml:"locale" json:"locale,omitempty"`
+	// Channels overrides MentionOnly per channel; values are one of listenModes
+	Channels map[string]string `yaml:"channels" json:"channels,omitempty"`
+	// Prefix such as !ika triggers searches like mentions
+	Prefix string `yaml:"prefix" json:"prefix,omitempty"`
+}
+
+const (
+	ListenAll     = "all"
+	ListenMention = "mention"
+	ListenIgnore  = "ignore"
+)
+
+var listenModes = []string{ListenAll, ListenMention, ListenIgnore}
+
+var guildDefaultModes = []string{"REGULAR", "OPEN", "CHALLENGE", "X", "SALMON", "BANKARA"}
+
+var guildLocales = []string{"ja", "en"}
+
+// containsSetting reports whether value is one of values; settings are compared after Normalize
+func containsSetting(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize converts values written by hand in the config file such as default_mode: challenge
+func (s GuildSettings) Normalize() GuildSettings {
+	s.DefaultMode = strings.ToUpper(s.DefaultMode)
+	s.Locale = strings.ToLower(s.Locale)
+	if s.Channels != nil {
+		channels := map[string]string{}
+		for channelID, mode := range s.Channels {
+			channels[channelID] = strings.ToLower(mode)
+		}
+		s.Channels = channels
+	}
+	return s
+}
+
+func (s GuildSettings) Validate() error {
+	if s.DefaultMode != "" && !containsSetting(guildDefaultModes, s.DefaultMode) {
+		return fmt.Errorf("unknown default_mode '%s'", s.DefaultMode)
+	}
+	if s.Locale != "" && !containsSetting(guildLocales, s.Locale) {
+		return fmt.Errorf("unknown locale '%s'", s.Locale)
+	}
+	for channelID, mode := range s.Channels {
+		if !containsSetting(listenModes, mode) {
+			return fmt.Errorf("unknown mode '%s' for channel %s", mode, channelID)
+		}
+	}
+	if strings.ContainsAny(s.Prefix, " \t\n") || utf8.RuneCountInString(s.Prefix) > 16 {
+		return fmt.Errorf("prefix must be a word up to 16 characters: '%s'", s.Prefix)
+	}
+	return nil
+}
+
+// ListenMode decides how to treat keyword inputs in the channel
+func (s GuildSettings) ListenMode(channelID string) string {
+	if mode, found := s.Channels[channelID]; found {
+		return mode
+	}
+	if s.MentionOnly {
+		return ListenMention
+	}
+	return ListenAll
+}
+
+// TrimPrefix removes the prefix and reports whether the input starts with it;
+// the prefix must be followed by spaces or the end not to match !ikabot with !ika
+func (s GuildSettings) TrimPrefix(input string) (string, bool) {
+	if s.Prefix == "" || !strings.HasPrefix(input, s.Prefix) {
+		return input, false
+	}
+	rest := strings.TrimPrefix(input, s.Prefix)
+	if first, _ := utf8.DecodeRuneInString(rest); rest != "" && !unicode.IsSpace(first) {
+		return input, false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// SetChannelMode changes the listen mode of the channel; "default" follows MentionOnly
+func (s *GuildSettings) SetChannelMode(channelID string, mode string) error {
+	mode = strings.ToLower(mode)
+	if mode != "default" && !containsSetting(listenModes, mode) {
+		return fmt.Errorf("unknown channel mode '%s'", mode)
+	}
+	// copy not to modify the map shared with the store
+	channels := map[string]string{}
+	for id, m := range s.Channels {
+		channels[id] = m
+	}
+	if mode == "default" {
+		delete(channels, channelID)
+	} else {
+		channels[channelID] = mode
+	}
+	if len(channels) == 0 {
+		channels = nil
+	}
+	s.Channels = channels
+	return nil
+}
+
+// Update changes a setting by its name as shown in the config file
+func (s *GuildSettings) Update(key string, value string) error {
+	updated := *s
+	switch key {
+	case "mention_only":
+		mentionOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("mention_only must be true or false: '%s'", value)
+		}
+		updated.MentionOnly = mentionOnly
+	case "default_mode":
+		updated.DefaultMode = strings.ToUpper(value)
+		if updated.DefaultMode == "NONE" {
+			updated.DefaultMode = ""
+		}
+	case "locale":
+		updated.Locale = strings.ToLower(value)
+	case "prefix":
+		updated.Prefix = value
+		if strings.ToLower(value) == "none" {
+			updated.Prefix = ""
+		}
+	default:
+		return fmt.Errorf("unknown setting '%s'", key)
+	}
+	err := updated.Validate()
+	if err != nil {
+		return err
+	}
+	*s = updated
+	return nil
+}
+
+// GuildSettingsStore keeps settings changed by /config; defaults come from the config file
+type GuildSettingsStore struct {
+	sync.RWMutex
+	cache    *FileCache
+	settings map[string]GuildSettings
+}
+
+func NewGuildSettingsStore(workdir string, cacheName string) *GuildSettingsStore {
+	gs := &GuildSettingsStore{
+		cache:    NewFileCache(workdir, cacheName),
+		settings: map[string]GuildSettings{},
+	}
+	// settings never expire
+	if restored := MaybeGetFromFileCache[map[string]GuildSettings](gs.cache, time.Hour*24*365*100); restored != nil {
+		gs.settings = *restored
+	}
+	return gs
+}
+
+func (gs *GuildSettingsStore) Get(guildID string) GuildSettings {
+	gs.RLock()
+	defer gs.RUnlock()
+	if settings, found := gs.settings[guildID]; found {
+		return settings
+	}
+	return getConfig().Guild(guildID)
+}
+
+func (gs *GuildSettingsStore) Set(guildID string, settings GuildSettings) error {
+	err := settings.Validate()
+	if err != nil {
+		return err
+	}
+	gs.Lock()
+	defer gs.Unlock()
+	gs.settings[guildID] = settings
+	return gs.persist()
+}
+
+// Reset removes settings of the guild to use the defaults again
+func (gs *GuildSettingsStore) Reset(guildID string) error {
+	gs.Lock()
+	defer gs.Unlock()
+	delete(gs.settings, guildID)
+	return gs.persist()
+}
+
+func (gs *GuildSettingsStore) persist() error {
+	// copy to avoid sharing the map with the cache
+	persisted := make(map[string]GuildSettings, len(gs.settings))
+	for guildID, settings := range gs.settings {
+		persisted[guildID] = settings
+	}
+	_, err := gs.cache.Put(&persisted)
+	return err
+}
+
+// ApplyOptions updates settings by options of /config in the order of guildSettingKeys;
+// channel_mode is applied to the channel given by the channel option
+func (s GuildSettings) ApplyOptions(options map[string]string) (GuildSettings, error) {
+	for _, key := range guildSettingKeys {
+		if value, found := options[key]; found {
+			err := s.Update(key, value)
+			if err != nil {
+				return s, err
+			}
+		}
+	}
+	if mode, found := options["channel_mode"]; found {
+		if options["channel"] == "" {
+			return s, fmt.Errorf("channel is required to change channel_mode")
+		}
+		err := s.SetChannelMode(options["channel"], mode)
+		if err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+var guildSettingKeys = []string{"mention_only", "default_mode", "locale", "prefix"}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s GuildSettings) Describe() string {
+	defaultMode := s.DefaultMode
+	if defaultMode == "" {
+		defaultMode = "none"
+	}
+	locale := s.Locale
+	if locale == "" {
+		locale = "ja"
+	}
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "none"
+	}
+	description := fmt.Sprintf("mention_only: %t\ndefault_mode: %s\nlocale: %s\nprefix: %s", s.MentionOnly, defaultMode, locale, prefix)
+	for _, channelID := range sortedKeys(s.Channels) {
+		description += fmt.Sprintf("\n<#%s>: %s", channelID, s.Channels[channelID])
+	}
+	return description
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGuildSettings_ApplyOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    GuildSettings
+		wantErr bool
+	}{
+		{
+			name:    "no options must keep settings",
+			options: map[string]string{},
+			want:    GuildSettings{DefaultMode: "OPEN", Locale: "en"},
+		},
+		{
+			name:    "options must be applied",
+			options: map[string]string{"mention_only": "true", "default_mode": "X", "locale": "ja"},
+			want:    GuildSettings{MentionOnly: true, DefaultMode: "X", Locale: "ja"},
+		},
+		{
+			name:    "NONE must clear the default mode",
+			options: map[string]string{"default_mode": "NONE"},
+			want:    GuildSettings{Locale: "en"},
+		},
+		{
+			name:    "channel_mode must be applied to the channel",
+			options: map[string]string{"channel_mode": "ignore", "channel": "10", "prefix": "!ika"},
+			want:    GuildSettings{DefaultMode: "OPEN", Locale: "en", Channels: map[string]string{"10": ListenIgnore}, Prefix: "!ika"},
+		},
+		{
+			name:    "channel_mode without channel must be rejected",
+			options: map[string]string{"channel_mode": "all"},
+			wantErr: true,
+		},
+		{
+			name:    "prefix with spaces must be rejected",
+			options: map[string]string{"prefix": "hey bot"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode must be rejected",
+			options: map[string]string{"default_mode": "FEST"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown locale must be rejected",
+			options: map[string]string{"locale": "fr"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GuildSettings{DefaultMode: "OPEN", Locale: "en"}.ApplyOptions(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuildSettings_Validate(t *testing.T) {
+	if err := (GuildSettings{DefaultMode: "challenge"}).Validate(); err == nil {
+		t.Errorf("Validate() must be case-sensitive; normalize settings before validation")
+	}
+	if err := (GuildSettings{DefaultMode: "challenge", Locale: "EN"}).Normalize().Validate(); err != nil {
+		t.Errorf("Normalize().Validate() = %v, want nil", err)
+	}
+}
+
+func TestGuildSettingsStore(t *testing.T) {
+	config := DefaultConfig()
+	config.Guilds = map[string]GuildSettings{"1": {MentionOnly: true}}
+	setConfig(config)
+	defer setConfig(nil)
+
+	workdir := t.TempDir()
+	store := NewGuildSettingsStore(workdir, "guild_settings")
+	if got := store.Get("1"); !got.MentionOnly {
+		t.Errorf("defaults in the config must be used; got %v", got)
+	}
+	if err := store.Set("1", GuildSettings{DefaultMode: "X"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("2", GuildSettings{Locale: "klingon"}); err == nil {
+		t.Errorf("invalid settings must be rejected")
+	}
+
+	restored := NewGuildSettingsStore(workdir, "guild_settings")
+	if got := restored.Get("1"); !reflect.DeepEqual(got, GuildSettings{DefaultMode: "X"}) {
+		t.Errorf("settings must be restored; got %v", got)
+	}
+	if err := restored.Reset("1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Get("1"); !got.MentionOnly || got.DefaultMode != "" {
+		t.Errorf("reset must restore defaults in the config; got %v", got)
+	}
+}
+
+func TestGuildSettings_ListenMode(t *testing.T) {
+	settings := GuildSettings{MentionOnly: true, Prefix: "!ika"}
+	_ = settings.SetChannelMode("10", ListenAll)
+	_ = settings.SetChannelMode("20", ListenIgnore)
+	tests := []struct {
+		name      string
+		settings  GuildSettings
+		channelID string
+		want      string
+	}{
+		{name: "channel mode must override mention_only", settings: settings, channelID: "10", want: ListenAll},
+		{name: "ignored channel must be ignored", settings: settings, channelID: "20", want: ListenIgnore},
+		{name: "other channels must follow mention_only", settings: settings, channelID: "30", want: ListenMention},
+		{name: "defaults must listen to all", settings: GuildSettings{}, channelID: "30", want: ListenAll},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.ListenMode(tt.channelID); got != tt.want {
+				t.Errorf("ListenMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_ = settings.SetChannelMode("10", "default")
+	if got := settings.ListenMode("10"); got != ListenMention {
+		t.Errorf("default must remove the channel mode; got %v", got)
+	}
+}
+
+func TestGuildSettings_TrimPrefix(t *testing.T) {
+	settings := GuildSettings{Prefix: "!ika"}
+	if got, prefixed := settings.TrimPrefix("!ika 次のガチマ"); got != "次のガチマ" || !prefixed {
+		t.Errorf("TrimPrefix() = %v, %v", got, prefixed)
+	}
+	if got, prefixed := settings.TrimPrefix("次のガチマ"); got != "次のガチマ" || prefixed {
+		t.Errorf("TrimPrefix() = %v, %v", got, prefixed)
+	}
+	if got, prefixed := settings.TrimPrefix("!ika　次のガチマ"); got != "次のガチマ" || !prefixed {
+		t.Errorf("TrimPrefix() = %v, %v", got, prefixed)
+	}
+	if got, prefixed := settings.TrimPrefix("!ika"); got != "" || !prefixed {
+		t.Errorf("TrimPrefix() = %v, %v", got, prefixed)
+	}
+	if got, prefixed := settings.TrimPrefix("!ikabot 次のガチマ"); got != "!ikabot 次のガチマ" || prefixed {
+		t.Errorf("prefix must not match a longer word; got %v, %v", got, prefixed)
+	}
+	if _, prefixed := (GuildSettings{}).TrimPrefix("!ika 次のガチマ"); prefixed {
+		t.Errorf("empty prefix must not match")
+	}
+}
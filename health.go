@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+)
+
+// HealthCheck returns nil if the component is working
+type HealthCheck func() error
+
+type HealthChecks struct {
+	sync.RWMutex
+	checks map[string]HealthCheck
+}
+
+type healthView struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func NewHealthChecks() *HealthChecks {
+	return &HealthChecks{checks: map[string]HealthCheck{}}
+}
+
+func (hc *HealthChecks) Add(name string, check HealthCheck) {
+	hc.Lock()
+	defer hc.Unlock()
+	hc.checks[name] = check
+}
+
+// Run runs all checks and reports whether all of them are passed
+func (hc *HealthChecks) Run() (healthView, bool) {
+	hc.RLock()
+	defer hc.RUnlock()
+	view := healthView{Status: "ok", Checks: map[string]string{}}
+	healthy := true
+	for name, check := range hc.checks {
+		if err := check(); err != nil {
+			view.Checks[name] = err.Error()
+			healthy = false
+		} else {
+			view.Checks[name] = "ok"
+		}
+	}
+	if !healthy {
+		view.Status = "unavailable"
+	}
+	return view, healthy
+}
+
+func (hc *HealthChecks) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		view, healthy := hc.Run()
+		if healthy {
+			writeJSON(w, http.StatusOK, view)
+		} else {
+			writeJSON(w, http.StatusServiceUnavailable, view)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHTTPServer_Health(t *testing.T) {
+	hs := NewHTTPServer(NewBot(newTestStore()))
+	hs.Liveness.Add("discord_gateway", func() error { return nil })
+	hs.Readiness.Add("schedule", func() error { return nil })
+	hs.Readiness.Add("discord_commands", func() error { return errors.New("slash commands are not registered") })
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		want       healthView
+	}{
+		{
+			name:       "healthz must be ok when all checks are passed",
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+			want:       healthView{Status: "ok", Checks: map[string]string{"discord_gateway": "ok"}},
+		},
+		{
+			name:       "readyz must be unavailable when any check is failed",
+			path:       "/readyz",
+			wantStatus: http.StatusServiceUnavailable,
+			want: healthView{Status: "unavailable", Checks: map[string]string{
+				"schedule":         "ok",
+				"discord_commands": "slash commands are not registered",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			hs.Handler().ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			var got healthView
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleStore_CheckFreshness(t *testing.T) {
+	workdir := t.TempDir()
+	ss := &ScheduleStore{
+		cache:       NewFileCache(workdir, "api_call_cache"),
+		salmonCache: NewFileCache(workdir, "api_call_cache_salmon"),
+	}
+	if err := ss.CheckFreshness(time.Hour); err == nil {
+		t.Errorf("CheckFreshness() must fail before loading schedules")
+	}
+
+	ss.info = newTestStore().info
+	ss.salmonInfo = newTestStore().salmonInfo
+	_, _ = ss.cache.Put(ss.info)
+	_, _ = ss.salmonCache.Put(ss.salmonInfo)
+	if err := ss.CheckFreshness(time.Hour); err != nil {
+		t.Errorf("CheckFreshness() = %v, want nil", err)
+	}
+
+	ss.salmonCache.FileCacheBody.Updated = time.Now().Add(-time.Hour * 3)
+	if err := ss.CheckFreshness(time.Hour); err == nil {
+		t.Errorf("CheckFreshness() must fail for outdated schedules")
+	}
+}
+
+func TestScheduleStore_RefreshEvery(t *testing.T) {
+	workdir := t.TempDir()
+	ss := &ScheduleStore{
+		cache:       NewFileCache(workdir, "api_call_cache"),
+		salmonCache: NewFileCache(workdir, "api_call_cache_salmon"),
+	}
+	_, _ = ss.cache.Put(newTestStore().info)
+	_, _ = ss.salmonCache.Put(newTestStore().salmonInfo)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		ss.RefreshEvery(time.Millisecond*10, stop)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for ss.CheckFreshness(time.Hour) != nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if err := ss.CheckFreshness(time.Hour); err != nil {
+		t.Errorf("RefreshEvery() must load schedules; CheckFreshness() = %v", err)
+	}
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("RefreshEvery() must return after stop is closed")
+	}
+}
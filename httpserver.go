@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type HTTPServer struct {
+	Core *Bot
+	// Liveness and Readiness are served as /healthz and /readyz for container probes
+	Liveness  *HealthChecks
+	Readiness *HealthChecks
+	mux       *http.ServeMux
+}
+
+type QueryView struct {
+	Text          string `json:"text,omitempty"`
+	Mode          string `json:"mode"`
+	Rule          string `json:"rule,omitempty"`
+	RelativeIndex string `json:"relative_index,omitempty"`
+	TimeIndex     string `json:"time_index,omitempty"`
+}
+
+type SearchResultView struct {
+	Query QueryView  `json:"query"`
+	Found bool       `json:"found"`
+	Slots []SlotView `json:"slots"`
+}
+
+type errorView struct {
+	Error string `json:"error"`
+}
+
+// modes accepted by the API; includes pseudo modes for searching multiple modes
+var apiModes = []string{"REGULAR", "OPEN", "CHALLENGE", "X", "SALMON", "BANKARA", "BYRULE", "ALL"}
+
+func createSearchResultView(sr SearchResult) SearchResultView {
+	view := SearchResultView{
+		Query: QueryView{
+			Text:          sr.Query.OriginalText,
+			Mode:          sr.Query.Mode.getIdentifier(),
+			Rule:          sr.Query.Rule,
+			RelativeIndex: sr.Query.RelativeIndex,
+			TimeIndex:     sr.Query.TimeIndex,
+		},
+		Found: sr.Found,
+		Slots: []SlotView{},
+	}
+	for _, slot := range sr.Slots {
+		view.Slots = append(view.Slots, createSlotView(slot))
+	}
+	return view
+}
+
+func NewHTTPServer(core *Bot) *HTTPServer {
+	hs := &HTTPServer{
+		Core:      core,
+		Liveness:  NewHealthChecks(),
+		Readiness: NewHealthChecks(),
+		mux:       http.NewServeMux(),
+	}
+	hs.mux.HandleFunc("/v1/schedule", hs.handleSchedule)
+	hs.mux.HandleFunc("/v1/query", hs.handleQuery)
+	hs.mux.HandleFunc("/v1/calendar.ics", hs.handleCalendar)
+	hs.mux.HandleFunc("/v1/feed.atom", hs.handleFeed)
+	hs.mux.Handle("/metrics", metricsHandler())
+	hs.mux.Handle("/healthz", hs.Liveness.Handler())
+	hs.mux.Handle("/readyz", hs.Readiness.Handler())
+	return hs
+}
+
+func (hs *HTTPServer) Handler() http.Handler {
+	return hs.mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// allow browser-based overlay tools
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		logger.Sugar().Error(err)
+	}
+}
+
+func isValidAPIMode(mode string) bool {
+	for _, m := range apiModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /v1/schedule?mode=X&rule=AREA&next=1&time=19
+func (hs *HTTPServer) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorView{"method not allowed"})
+		return
+	}
+	params := r.URL.Query()
+	mode := strings.ToUpper(params.Get("mode"))
+	rule := strings.ToUpper(params.Get("rule"))
+	if mode == "" && rule != "" {
+		mode = "BYRULE"
+	}
+	if !isValidAPIMode(mode) {
+		writeJSON(w, http.StatusBadRequest, errorView{"invalid mode: " + params.Get("mode")})
+		return
+	}
+	query := &SearchQuery{
+		Mode: getMode(mode),
+		Rule: rule,
+	}
+	if next := params.Get("next"); next != "" {
+		if _, err := strconv.Atoi(next); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorView{"invalid next: " + next})
+			return
+		}
+		query.RelativeIndex = next
+	}
+	if hour := params.Get("time"); hour != "" {
+		if _, err := strconv.Atoi(hour); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorView{"invalid time: " + hour})
+			return
+		}
+		query.TimeIndex = hour
+	}
+	writeJSON(w, http.StatusOK, createSearchResultView(hs.Core.Search(query)))
+}
+
+// GET /v1/query?q=次のガチマ
+func (hs *HTTPServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorView{"method not allowed"})
+		return
+	}
+	query := ParseLoose(NormalizeInput(r.URL.Query().Get("q")))
+	if query.OriginalText == "" {
+		writeJSON(w, http.StatusBadRequest, errorView{"query is not understood"})
+		return
+	}
+	writeJSON(w, http.StatusOK, createSearchResultView(hs.Core.Search(query)))
+}
+
+// GET /v1/calendar.ics?mode=X,CHALLENGE&rule=LOFT&stage=ユノハナ大渓谷&bigrun=1
+func (hs *HTTPServer) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorView{"method not allowed"})
+		return
+	}
+	filter, err := ParseCalendarFilter(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorView{err.Error()})
+		return
+	}
+	hs.Core.Store.MaybeRefresh()
+	info, salmonInfo := hs.Core.Store.Snapshot()
+	ics := GenerateICS(collectCalendarSlots(info, salmonInfo, filter), "ikabot3", time.Now())
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="ikabot3.ics"`)
+	_, err = w.Write([]byte(ics))
+	if err != nil {
+		logger.Sugar().Error(err)
+	}
+}
+
+// GET /v1/feed.atom
+func (hs *HTTPServer) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorView{"method not allowed"})
+		return
+	}
+	hs.Core.Store.MaybeRefresh()
+	selfURL := strings.TrimSuffix(hs.Core.PublicURL, "/") + "/v1/feed.atom"
+	body, err := GenerateAtom(hs.Core.Store.Events(), selfURL, time.Now())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorView{err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	_, err = w.Write(body)
+	if err != nil {
+		logger.Sugar().Error(err)
+	}
+}
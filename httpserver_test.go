@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestHTTPServer_API(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantModes  []string
+		wantRules  []string
+	}{
+		{
+			name:       "schedule by mode",
+			path:       "/v1/schedule?mode=x",
+			wantStatus: http.StatusOK,
+			wantModes:  []string{"X"},
+			wantRules:  []string{"GOAL"},
+		},
+		{
+			name:       "schedule by mode and rule with next",
+			path:       "/v1/schedule?mode=CHALLENGE&rule=AREA&next=1",
+			wantStatus: http.StatusOK,
+			wantModes:  []string{"CHALLENGE"},
+			wantRules:  []string{"AREA"},
+		},
+		{
+			name:       "schedule by rule only must search multiple modes",
+			path:       "/v1/schedule?rule=CLAM",
+			wantStatus: http.StatusOK,
+			wantModes:  []string{"CHALLENGE", "OPEN", "X"},
+			wantRules:  []string{"CLAM", "CLAM", "CLAM"},
+		},
+		{
+			name:       "schedule with invalid mode",
+			path:       "/v1/schedule?mode=UNKNOWN",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "schedule with invalid next",
+			path:       "/v1/schedule?mode=X&next=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "query by keywords",
+			path:       "/v1/query?q=" + url.QueryEscape("次のガチマ"),
+			wantStatus: http.StatusOK,
+			wantModes:  []string{"CHALLENGE"},
+			wantRules:  []string{"LOFT"},
+		},
+		{
+			name:       "query by Salmon Run keywords",
+			path:       "/v1/query?q=" + url.QueryEscape("次の次のシャケ"),
+			wantStatus: http.StatusOK,
+			wantModes:  []string{"BIGRUN"},
+			wantRules:  []string{""},
+		},
+		{
+			name:       "query not understood",
+			path:       "/v1/query?q=" + url.QueryEscape("こんにちは"),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	hs := NewHTTPServer(NewBot(newTestStore()))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			hs.Handler().ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %v", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var view SearchResultView
+			err := json.Unmarshal(rec.Body.Bytes(), &view)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !view.Found || len(view.Slots) != len(tt.wantModes) {
+				t.Fatalf("result = %v", rec.Body.String())
+			}
+			for idx, slot := range view.Slots {
+				rule := ""
+				if slot.Rule != nil {
+					rule = slot.Rule.Key
+				}
+				if slot.Mode != tt.wantModes[idx] || rule != tt.wantRules[idx] {
+					t.Errorf("slots[%d] = %v %v, want %v %v", idx, slot.Mode, rule, tt.wantModes[idx], tt.wantRules[idx])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	LocaleJA = "ja"
+	LocaleEN = "en"
+)
+
+// messageCatalog holds messages for each locale; LocaleJA must contain all keys
+var messageCatalog = map[string]map[string]string{
+	LocaleJA: {
+		"not_found":            "Not Found!",
+		"invalid_command":      "Invalid command!",
+		"calendar_disabled":    "Calendar feed is not available!",
+		"time_range":           "%d/%d %d時～%d/%d %d時",
+		"event_title":          "%s（%s）",
+		"period":               "%s（%s）",
+		"starts_in":            "開始まであと%s",
+		"ends_in":              "終了まであと%s",
+		"duration_minutes":     "%d分",
+		"duration_hours":       "%d時間%d分",
+		"discord_starts":       "%sに開始",
+		"discord_ends":         "%sに終了",
+		"time_point":           "%d/%d %d時",
+		"answer_starts":        "%sから（%s）",
+		"answer_running":       "開催中（%s）",
+		"answer_ends":          "%sまで（%s）",
+		"answer_finished":      "終了しました",
+		"did_you_mean":         "もしかして: %s？",
+		"unknown_name":         "「%s」は見つかりませんでした！",
+		"too_many_queries":     "キーワードは %d 個までです！",
+		"mode_short_REGULAR":   "レギュラー",
+		"mode_short_OPEN":      "オープン",
+		"mode_short_CHALLENGE": "チャレンジ",
+		"mode_short_X":         "X",
+		"mode_short_SALMON":    "サーモンラン",
+		"mode_short_BIGRUN":    "ビッグラン",
+		"settings_dm":          "このコマンドはサーバ内でのみ利用できます。",
+		"settings_permission":  "設定の変更にはサーバ管理の権限が必要です。",
+		"settings_reset":       "設定を既定値に戻しました。",
+		"settings_save_error":  "設定を保存できませんでした！",
+	},
+	LocaleEN: {
+		"not_found":            "Not Found!",
+		"invalid_command":      "Invalid command!",
+		"calendar_disabled":    "Calendar feed is not available!",
+		"time_range":           "%d/%d %d:00 - %d/%d %d:00",
+		"event_title":          "%s (%s)",
+		"period":               "%s (%s)",
+		"starts_in":            "starts in %s",
+		"ends_in":              "ends in %s",
+		"duration_minutes":     "%dm",
+		"duration_hours":       "%dh %dm",
+		"discord_starts":       "starts %s",
+		"discord_ends":         "ends %s",
+		"time_point":           "%d/%d %d:00",
+		"answer_starts":        "from %s (%s)",
+		"answer_running":       "now (%s)",
+		"answer_ends":          "until %s (%s)",
+		"answer_finished":      "finished",
+		"did_you_mean":         "Did you mean: %s?",
+		"unknown_name":         "Unknown name: %s!",
+		"too_many_queries":     "Up to %d keywords at once!",
+		"mode_short_REGULAR":   "Regular",
+		"mode_short_OPEN":      "Open",
+		"mode_short_CHALLENGE": "Series",
+		"mode_short_X":         "X",
+		"mode_short_SALMON":    "Salmon Run",
+		"mode_short_BIGRUN":    "Big Run",
+		"settings_dm":          "This command is only available in servers.",
+		"settings_permission":  "You need the Manage Server permission to change settings.",
+		"settings_reset":       "Settings are reset.",
+		"settings_save_error":  "Cannot save settings!",
+	},
+}
+
+// normalizeLocale converts locales such as en-US given by Discord; unknown locales are treated as LocaleJA
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(locale)
+	if locale == LocaleEN || strings.HasPrefix(locale, LocaleEN+"-") {
+		return LocaleEN
+	}
+	return LocaleJA
+}
+
+func localize(locale string, key string, args ...interface{}) string {
+	message, found := messageCatalog[normalizeLocale(locale)][key]
+	if !found {
+		message = messageCatalog[LocaleJA][key]
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// localizeName translates a Japanese name from the upstream API with dictionaries
+func localizeName(dictionary []DictionaryEntry, name string, locale string) string {
+	if normalizeLocale(locale) != LocaleEN {
+		return name
+	}
+	for _, entry := range dictionary {
+		if entry.Name == name && entry.EnglishName != "" {
+			return entry.EnglishName
+		}
+	}
+	return name
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type CalendarFilter struct {
+	// Modes contain REGULAR, OPEN, CHALLENGE, X, SALMON or BIGRUN; empty means all
+	Modes      []string
+	Rule       string
+	Stage      string
+	BigRunOnly bool
+}
+
+var calendarModes = []string{"REGULAR", "OPEN", "CHALLENGE", "X"}
+
+// ParseCalendarFilter returns UnknownNameError if the rule or the stage is not in dictionaries
+func ParseCalendarFilter(params url.Values) (CalendarFilter, error) {
+	var filter CalendarFilter
+	for _, mode := range params["mode"] {
+		for _, m := range strings.Split(mode, ",") {
+			if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+				filter.Modes = append(filter.Modes, m)
+			}
+		}
+	}
+	var err error
+	if rule := params.Get("rule"); rule != "" {
+		if filter.Rule, err = resolveDictionaryValue(RuleDictionary, rule); err != nil {
+			return filter, err
+		}
+	}
+	if stage := params.Get("stage"); stage != "" {
+		if filter.Stage, err = resolveDictionaryValue(StageDictionary, stage); err != nil {
+			return filter, err
+		}
+	}
+	bigrun := strings.ToLower(params.Get("bigrun"))
+	filter.BigRunOnly = bigrun == "1" || bigrun == "true"
+	return filter, nil
+}
+
+func (filter CalendarFilter) Values() url.Values {
+	params := url.Values{}
+	if len(filter.Modes) > 0 {
+		params.Set("mode", strings.Join(filter.Modes, ","))
+	}
+	if filter.Rule != "" {
+		params.Set("rule", filter.Rule)
+	}
+	if filter.Stage != "" {
+		params.Set("stage", filter.Stage)
+	}
+	if filter.BigRunOnly {
+		params.Set("bigrun", "1")
+	}
+	return params
+}
+
+func (filter CalendarFilter) accepts(srs SearchResultSlot) bool {
+	modeKey := slotModeKey(srs)
+	if filter.BigRunOnly && modeKey != "BIGRUN" {
+		return false
+	}
+	if !containsOrEmpty(filter.Modes, modeKey) && !(modeKey == "BIGRUN" && containsOrEmpty(filter.Modes, "SALMON")) {
+		return false
+	}
+	if srs.tsi.IsFest {
+		return false
+	}
+	isSalmon := srs.mode.getIdentifier() == "SALMON"
+	if filter.Rule != "" && (isSalmon || srs.tsi.Rule.Key != filter.Rule) {
+		return false
+	}
+	if filter.Stage != "" {
+		if isSalmon {
+			return srs.tsi.Stage.Name == filter.Stage
+		}
+		for _, stage := range srs.tsi.Stages {
+			if stage.Name == filter.Stage {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func collectCalendarSlots(info *AllScheduleInfo, salmonInfo *[]TimeSlotInfo, filter CalendarFilter) []SearchResultSlot {
+	var slots []SearchResultSlot
+	if info != nil {
+		for _, mode := range calendarModes {
+			tsinfos := info.getTimeSlotInfoByMode(getMode(mode))
+			for idx := range tsinfos {
+				srs := SearchResultSlot{getMode(mode), &tsinfos[idx]}
+				if filter.accepts(srs) {
+					slots = append(slots, srs)
+				}
+			}
+		}
+	}
+	if salmonInfo != nil {
+		for idx := range *salmonInfo {
+			tsinfo := &(*salmonInfo)[idx]
+			mode := getMode("SALMON")
+			if tsinfo.IsBigRun {
+				mode = getMode("BIGRUN")
+			}
+			srs := SearchResultSlot{mode, tsinfo}
+			if filter.accepts(srs) {
+				slots = append(slots, srs)
+			}
+		}
+	}
+	return slots
+}
+
+func getJST() *time.Location {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		// tzdata may be missing in containers
+		return time.FixedZone("JST", 9*60*60)
+	}
+	return loc
+}
+
+// escapeICSText escapes TEXT values as described in RFC 5545 section 3.3.11
+func escapeICSText(input string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(input)
+}
+
+// foldICSLine splits lines longer than 75 octets without breaking UTF-8 sequences
+func foldICSLine(line string) string {
+	var sb strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > 75 {
+			sb.WriteString("\r\n ")
+			// the leading space is counted
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += size
+	}
+	return sb.String()
+}
+
+func calendarUID(srs SearchResultSlot) string {
+	return fmt.Sprintf("%s-%s@ikabot3", slotModeKey(srs), srs.tsi.StartTime.UTC().Format("20060102T150405Z"))
+}
+
+func GenerateICS(slots []SearchResultSlot, name string, timeStamp time.Time) string {
+	jst := getJST()
+	var lines []string
+	lines = append(lines,
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//ikabot3//schedule//JA",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:"+escapeICSText(name),
+		"X-WR-TIMEZONE:Asia/Tokyo",
+		"BEGIN:VTIMEZONE",
+		"TZID:Asia/Tokyo",
+		"BEGIN:STANDARD",
+		"DTSTART:19700101T000000",
+		"TZOFFSETFROM:+0900",
+		"TZOFFSETTO:+0900",
+		"TZNAME:JST",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+	)
+	for _, srs := range slots {
+		card := createResponseCard(srs)
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+calendarUID(srs),
+			"DTSTAMP:"+timeStamp.UTC().Format("20060102T150405Z"),
+			"DTSTART;TZID=Asia/Tokyo:"+srs.tsi.StartTime.In(jst).Format("20060102T150405"),
+			"DTEND;TZID=Asia/Tokyo:"+srs.tsi.EndTime.In(jst).Format("20060102T150405"),
+			"SUMMARY:"+escapeICSText(card.ModeName+" "+card.Title),
+			"DESCRIPTION:"+escapeICSText(strings.Join(card.Lines, "\n")),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(foldICSLine(line))
+		sb.WriteString("\r\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_foldICSLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("あ", 40)
+	folded := foldICSLine(line)
+	for _, l := range strings.Split(folded, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("line exceeds 75 octets: %d", len(l))
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("unfolded = %v, want %v", unfolded, line)
+	}
+}
+
+func TestGenerateICS(t *testing.T) {
+	store := newTestStore()
+	tests := []struct {
+		name     string
+		params   string
+		wantUIDs []string
+	}{
+		{
+			name:     "X Match Splat Zones",
+			params:   "mode=x&rule=AREA",
+			wantUIDs: []string{"X-20230302T060000Z@ikabot3"},
+		},
+		{
+			name:     "rule names must be resolved",
+			params:   "mode=CHALLENGE,OPEN&rule=" + url.QueryEscape("がちあさり"),
+			wantUIDs: []string{"OPEN-20230302T060000Z@ikabot3", "CHALLENGE-20230302T080000Z@ikabot3"},
+		},
+		{
+			name:     "stage filter",
+			params:   "mode=REGULAR&stage=" + url.QueryEscape("ゆのはなだいけいこく"),
+			wantUIDs: []string{"REGULAR-20230302T020000Z@ikabot3"},
+		},
+		{
+			name:     "Big Run only",
+			params:   "bigrun=1",
+			wantUIDs: []string{"BIGRUN-20230304T160000Z@ikabot3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := url.ParseQuery(tt.params)
+			filter, err := ParseCalendarFilter(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slots := collectCalendarSlots(store.info, store.salmonInfo, filter)
+			ics := GenerateICS(slots, "ikabot3", testNow)
+			var uids []string
+			for _, line := range strings.Split(ics, "\r\n") {
+				if strings.HasPrefix(line, "UID:") {
+					uids = append(uids, strings.TrimPrefix(line, "UID:"))
+				}
+			}
+			if strings.Join(uids, " ") != strings.Join(tt.wantUIDs, " ") {
+				t.Errorf("UIDs = %v, want %v", uids, tt.wantUIDs)
+			}
+		})
+	}
+}
+
+func TestParseCalendarFilter_UnknownStage(t *testing.T) {
+	params, _ := url.ParseQuery("stage=" + url.QueryEscape("ゆのはな"))
+	_, err := ParseCalendarFilter(params)
+	unknown, ok := err.(*UnknownNameError)
+	if !ok || unknown.Suggestion != "ユノハナ大渓谷" {
+		t.Errorf("ParseCalendarFilter() error = %v, want a suggestion of ユノハナ大渓谷", err)
+	}
+}
+
+func TestGenerateICS_TimeZone(t *testing.T) {
+	slot := TimeSlotInfo{
+		// given in UTC; must be rendered in JST
+		StartTime: time.Date(2023, 3, 2, 10, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2023, 3, 2, 12, 0, 0, 0, time.UTC),
+		Rule:      RuleInfo{Key: "LOFT", Name: "ガチヤグラ"},
+		Stages:    []StageInfo{{Name: "ユノハナ大渓谷"}, {Name: "ゴンズイ地区"}},
+	}
+	ics := GenerateICS([]SearchResultSlot{{getMode("X"), &slot}}, "ikabot3", testNow)
+	for _, want := range []string{
+		"DTSTART;TZID=Asia/Tokyo:20230302T190000\r\n",
+		"DTEND;TZID=Asia/Tokyo:20230302T210000\r\n",
+		"DTSTAMP:20230302T033000Z\r\n",
+		"SUMMARY:Xマッチ ガチヤグラ\r\n",
+		"DESCRIPTION:ユノハナ大渓谷\\nゴンズイ地区\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("ICS must contain %q:\n%s", want, ics)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type IRCBot struct {
+	Core     *Bot
+	Server   string
+	UseTLS   bool
+	Password string
+	Nick     string
+	Channels []string
+	// ListenAll treats every message in channels as a search even without mentions
+	ListenAll bool
+	// connLock guards conn replaced by reconnects and serializes writes
+	connLock sync.Mutex
+	conn     net.Conn
+	closed   bool
+}
+
+// errIRCClosed is returned by Run after Close to stop reconnecting
+var errIRCClosed = errors.New("IRC bot is closed")
+
+type IRCBotConfig struct {
+	Server    string
+	UseTLS    bool
+	Password  string
+	Nick      string
+	Channels  []string
+	ListenAll bool
+}
+
+type ircMessage struct {
+	Prefix  string
+	Command string
+	Params  []string
+}
+
+func NewIRCBot(core *Bot, config IRCBotConfig) *IRCBot {
+	return &IRCBot{
+		Core:      core,
+		Server:    config.Server,
+		UseTLS:    config.UseTLS,
+		Password:  config.Password,
+		Nick:      config.Nick,
+		Channels:  config.Channels,
+		ListenAll: config.ListenAll,
+	}
+}
+
+// parseIRCMessage parses a line as described in RFC 1459 section 2.3.1
+func parseIRCMessage(line string) ircMessage {
+	var msg ircMessage
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, ":") {
+		idx := strings.Index(line, " ")
+		if idx < 0 {
+			return ircMessage{Prefix: line[1:]}
+		}
+		msg.Prefix = line[1:idx]
+		line = line[idx+1:]
+	}
+	trailing := ""
+	hasTrailing := false
+	if idx := strings.Index(line, " :"); idx >= 0 {
+		trailing = line[idx+2:]
+		hasTrailing = true
+		line = line[:idx]
+	}
+	fields := strings.Fields(line)
+	if len(fields) > 0 {
+		msg.Command = strings.ToUpper(fields[0])
+		msg.Params = fields[1:]
+	}
+	if hasTrailing {
+		msg.Params = append(msg.Params, trailing)
+	}
+	return msg
+}
+
+func (msg ircMessage) senderNick() string {
+	if idx := strings.Index(msg.Prefix, "!"); idx >= 0 {
+		return msg.Prefix[:idx]
+	}
+	return msg.Prefix
+}
+
+func (ib *IRCBot) send(format string, args ...interface{}) error {
+	ib.connLock.Lock()
+	defer ib.connLock.Unlock()
+	return ib.writeLine(format, args...)
+}
+
+// writeLine writes to conn; callers must hold connLock
+func (ib *IRCBot) writeLine(format string, args ...interface{}) error {
+	if ib.conn == nil {
+		return errors.New("IRC is not connected")
+	}
+	_ = ib.conn.SetWriteDeadline(time.Now().Add(time.Second * 30))
+	_, err := fmt.Fprintf(ib.conn, format+"\r\n", args...)
+	return err
+}
+
+// stripMention removes "nick: " style addressing or the nick as a word; returns false if the bot is not called
+func (ib *IRCBot) stripMention(text string) (string, bool) {
+	nick := ib.Nick
+	if len(text) > len(nick) && strings.EqualFold(text[:len(nick)], nick) && strings.ContainsRune(":,", rune(text[len(nick)])) {
+		return strings.TrimSpace(text[len(nick)+1:]), true
+	}
+	words := strings.Fields(text)
+	for idx, word := range words {
+		// nicks are case-insensitive in IRC
+		if strings.EqualFold(word, nick) {
+			return strings.Join(append(words[:idx:idx], words[idx+1:]...), " "), true
+		}
+	}
+	return text, false
+}
+
+// createIRCLines splits replies into lines since a line break in PRIVMSG starts another IRC command
+func createIRCLines(resp BotResponse) []string {
+	texts := []string{resp.Text}
+	if len(resp.Cards) > 0 {
+		texts = nil
+		for _, card := range resp.Cards {
+			texts = append(texts, card.PlainText())
+		}
+	}
+	var lines []string
+	for _, text := range texts {
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimRight(line, "\r"); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+func (ib *IRCBot) handlePrivmsg(msg ircMessage) {
+	if len(msg.Params) < 2 {
+		return
+	}
+	target, text := msg.Params[0], msg.Params[1]
+	sender := msg.senderNick()
+	isChannel := strings.HasPrefix(target, "#") || strings.HasPrefix(target, "&")
+
+	text, mentioned := ib.stripMention(text)
+	if !isChannel {
+		// private messages are always addressed to the bot
+		mentioned = true
+		target = sender
+	}
+	if !mentioned && !ib.ListenAll {
+		return
+	}
+	resp := ib.Core.Handle(BotRequest{Text: text, Mentioned: mentioned, Transport: "irc"})
+	if resp.Ignored {
+		return
+	}
+	for _, line := range createIRCLines(resp) {
+		if isChannel {
+			line = sender + ": " + line
+		}
+		err := ib.send("PRIVMSG %s :%s", target, line)
+		if err != nil {
+			logger.Sugar().Error(err)
+			return
+		}
+	}
+}
+
+func (ib *IRCBot) handleMessage(msg ircMessage) error {
+	switch msg.Command {
+	case "PING":
+		return ib.send("PONG :%s", strings.Join(msg.Params, " "))
+	case "001":
+		// registered to the server
+		for _, channel := range ib.Channels {
+			err := ib.send("JOIN %s", channel)
+			if err != nil {
+				return err
+			}
+		}
+	case "PRIVMSG":
+		ib.handlePrivmsg(msg)
+	}
+	return nil
+}
+
+func (ib *IRCBot) dial() (net.Conn, error) {
+	if ib.UseTLS {
+		return tls.Dial("tcp", ib.Server, &tls.Config{})
+	}
+	return net.DialTimeout("tcp", ib.Server, time.Second*30)
+}
+
+// Run connects to the server and blocks until the connection is closed
+func (ib *IRCBot) Run() error {
+	conn, err := ib.dial()
+	if err != nil {
+		return err
+	}
+	ib.connLock.Lock()
+	if ib.closed {
+		ib.connLock.Unlock()
+		conn.Close()
+		return errIRCClosed
+	}
+	ib.conn = conn
+	ib.connLock.Unlock()
+	defer conn.Close()
+
+	if ib.Password != "" {
+		err = ib.send("PASS %s", ib.Password)
+		if err != nil {
+			return err
+		}
+	}
+	err = ib.send("NICK %s", ib.Nick)
+	if err != nil {
+		return err
+	}
+	err = ib.send("USER %s 0 * :%s", ib.Nick, getConfig().UserAgent)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		err = ib.handleMessage(parseIRCMessage(scanner.Text()))
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = scanner.Err()
+	}
+	// reading the connection closed by Close fails with "use of closed network connection"
+	ib.connLock.Lock()
+	defer ib.connLock.Unlock()
+	if ib.closed {
+		return errIRCClosed
+	}
+	return err
+}
+
+// Close disconnects from the server; Run returns errIRCClosed after that
+func (ib *IRCBot) Close() {
+	ib.connLock.Lock()
+	defer ib.connLock.Unlock()
+	ib.closed = true
+	if ib.conn != nil {
+		_ = ib.writeLine("QUIT :bye")
+		ib.conn.Close()
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parseIRCMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want ircMessage
+	}{
+		{
+			name: "PING must be parsed with a trailing parameter",
+			args: "PING :irc.example.org\r\n",
+			want: ircMessage{Command: "PING", Params: []string{"irc.example.org"}},
+		},
+		{
+			name: "PRIVMSG must be parsed with a prefix",
+			args: ":alice!a@example.org PRIVMSG #squad :ikabot3: 次のガチマ",
+			want: ircMessage{Prefix: "alice!a@example.org", Command: "PRIVMSG", Params: []string{"#squad", "ikabot3: 次のガチマ"}},
+		},
+		{
+			name: "numeric reply must be parsed",
+			args: ":irc.example.org 001 ikabot3 :Welcome",
+			want: ircMessage{Prefix: "irc.example.org", Command: "001", Params: []string{"ikabot3", "Welcome"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseIRCMessage(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIRCMessage() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIRCBot_stripMention(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          string
+		want          string
+		wantMentioned bool
+	}{
+		{name: "leading nick with colon must be a mention", args: "ikabot3: 次のガチマ", want: "次のガチマ", wantMentioned: true},
+		{name: "leading nick with comma must be a mention", args: "IkaBot3, 次のガチマ", want: "次のガチマ", wantMentioned: true},
+		{name: "nick as a word must be a mention", args: "hey ikabot3 次のガチマ", want: "hey 次のガチマ", wantMentioned: true},
+		{name: "nick in other words must not be a mention", args: "ikabot3000 次のガチマ", want: "ikabot3000 次のガチマ", wantMentioned: false},
+		{name: "nick with other characters must not be a mention", args: "myikabot3: 次のガチマ", want: "myikabot3: 次のガチマ", wantMentioned: false},
+	}
+	ib := NewIRCBot(nil, IRCBotConfig{Nick: "ikabot3"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, mentioned := ib.stripMention(tt.args)
+			if got != tt.want || mentioned != tt.wantMentioned {
+				t.Errorf("stripMention() = %q, %v, want %q, %v", got, mentioned, tt.want, tt.wantMentioned)
+			}
+		})
+	}
+}
+
+func Test_createIRCLines(t *testing.T) {
+	tests := []struct {
+		name string
+		args BotResponse
+		want []string
+	}{
+		{
+			name: "multi-line text must be split into lines",
+			args: BotResponse{Text: "```\ninput: 次のガチマ\r\n\nparser: japanese\n```"},
+			want: []string{"```", "input: 次のガチマ", "parser: japanese", "```"},
+		},
+		{
+			name: "single-line text must be kept",
+			args: BotResponse{Text: "Not Found!"},
+			want: []string{"Not Found!"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := createIRCLines(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createIRCLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIRCBot_Run(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	ib := NewIRCBot(NewBot(newTestStore()), IRCBotConfig{
+		Server:   listener.Addr().String(),
+		Nick:     "ikabot3",
+		Channels: []string{"#squad"},
+	})
+	done := make(chan error)
+	go func() {
+		done <- ib.Run()
+	}()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(time.Second * 5))
+	reader := bufio.NewReader(conn)
+	expect := func(prefix string) string {
+		t.Helper()
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("waiting %q: %v", prefix, err)
+		}
+		if !strings.HasPrefix(line, prefix) {
+			t.Fatalf("got %q, want prefix %q", line, prefix)
+		}
+		return strings.TrimRight(line, "\r\n")
+	}
+
+	expect("NICK ikabot3")
+	expect("USER ikabot3")
+	fmt.Fprint(conn, ":irc.example.org 001 ikabot3 :Welcome\r\n")
+	expect("JOIN #squad")
+	fmt.Fprint(conn, "PING :irc.example.org\r\n")
+	expect("PONG :irc.example.org")
+
+	// messages without mentions are ignored
+	fmt.Fprint(conn, ":alice!a@example.org PRIVMSG #squad :次のガチマ\r\n")
+	fmt.Fprint(conn, ":alice!a@example.org PRIVMSG #squad :ikabot3: 次のガチマ\r\n")
+	line := expect("PRIVMSG #squad :alice: ")
+	if !strings.Contains(line, "ガチヤグラ") {
+		t.Errorf("first line = %v", line)
+	}
+	line = expect("PRIVMSG #squad :alice: ")
+	if !strings.Contains(line, "ナメロウ金属 / クサヤ温泉") {
+		t.Errorf("second line = %v", line)
+	}
+
+	// private messages are replied to the sender
+	fmt.Fprint(conn, ":bob!b@example.org PRIVMSG ikabot3 :シャケ\r\n")
+	line = expect("PRIVMSG bob :")
+	if !strings.Contains(line, "シェケナダム") {
+		t.Errorf("private reply = %v", line)
+	}
+
+	expect("PRIVMSG bob :")
+
+	// Close must quit while Run is reading
+	ib.Close()
+	expect("QUIT :bye")
+	if err := <-done; !errors.Is(err, errIRCClosed) {
+		t.Errorf("Run() must return errIRCClosed after Close(); got %v", err)
+	}
+	if err := ib.Run(); !errors.Is(err, errIRCClosed) {
+		t.Errorf("Run() after Close() must return errIRCClosed; got %v", err)
+	}
+}
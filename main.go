@@ -1,13 +1,17 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"net/http"
 	_ "net/http/pprof"
@@ -15,18 +19,21 @@ import (
 
 var (
 	logger        *zap.Logger
+	logLevel      = zap.NewAtomicLevel()
 	scheduleStore ScheduleStore
 )
 
 type ModeInfo struct {
 	Mode
-	ModeName   string
-	Identifier string
-	Color      int
+	ModeName        string
+	EnglishModeName string
+	Identifier      string
+	Color           int
 }
 
 type Mode interface {
 	getModeName() string
+	getLocalizedModeName(locale string) string
 	getIdentifier() string
 	getColor() int
 }
@@ -35,6 +42,13 @@ func (mi ModeInfo) getModeName() string {
 	return mi.ModeName
 }
 
+func (mi ModeInfo) getLocalizedModeName(locale string) string {
+	if locale == LocaleEN && mi.EnglishModeName != "" {
+		return mi.EnglishModeName
+	}
+	return mi.ModeName
+}
+
 func (mi ModeInfo) getIdentifier() string {
 	return mi.Identifier
 }
@@ -48,34 +62,52 @@ var ModeTable map[string]ModeInfo
 func init() {
 	ModeTable = map[string]ModeInfo{
 		"OPEN": {
-			ModeName:   "バンカラマッチ（オープン）",
-			Identifier: "OPEN",
-			Color:      0xf64a10,
+			ModeName:        "バンカラマッチ（オープン）",
+			EnglishModeName: "Anarchy Battle (Open)",
+			Identifier:      "OPEN",
+			Color:           0xf64a10,
 		},
 		"CHALLENGE": {
-			ModeName:   "バンカラマッチ（チャレンジ）",
-			Identifier: "CHALLENGE",
-			Color:      0xf64a10,
+			ModeName:        "バンカラマッチ（チャレンジ）",
+			EnglishModeName: "Anarchy Battle (Series)",
+			Identifier:      "CHALLENGE",
+			Color:           0xf64a10,
 		},
 		"X": {
-			ModeName:   "Xマッチ",
-			Identifier: "X",
-			Color:      0x74f1a2,
+			ModeName:        "Xマッチ",
+			EnglishModeName: "X Battle",
+			Identifier:      "X",
+			Color:           0x74f1a2,
 		},
 		"SALMON": {
-			ModeName:   "サーモンラン",
-			Identifier: "SALMON",
-			Color:      0xff501e,
+			ModeName:        "サーモンラン",
+			EnglishModeName: "Salmon Run",
+			Identifier:      "SALMON",
+			Color:           0xff501e,
 		},
 		"BIGRUN": {
-			ModeName:   "ビッグラン",
-			Identifier: "SALMON",
-			Color:      0xfe0de8,
+			ModeName:        "ビッグラン",
+			EnglishModeName: "Big Run",
+			Identifier:      "SALMON",
+			Color:           0xfe0de8,
 		},
 		"REGULAR": {
-			ModeName:   "レギュラーマッチ",
-			Identifier: "REGULAR",
-			Color:      0xd0f623,
+			ModeName:        "レギュラーマッチ",
+			EnglishModeName: "Regular Battle",
+			Identifier:      "REGULAR",
+			Color:           0xd0f623,
+		},
+		"EVENT": {
+			ModeName:        "イベントマッチ",
+			EnglishModeName: "Challenge",
+			Identifier:      "EVENT",
+			Color:           0xf02d7d,
+		},
+		"FEST": {
+			ModeName:        "フェスマッチ",
+			EnglishModeName: "Splatfest Battle",
+			Identifier:      "FEST",
+			Color:           0xeae23a,
 		},
 	}
 }
@@ -93,14 +125,54 @@ func getMode(identifier string) Mode {
 	}
 }
 
+// splitList splits comma-separated values in environment variables
+func splitList(input string) []string {
+	var values []string
+	for _, value := range strings.Split(input, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// loadConfig loads the config file given by IKABOT3_CONFIG with overrides by environment variables
+func loadConfig() (*Config, error) {
+	return LoadConfig(os.Getenv("IKABOT3_CONFIG"), os.Getenv)
+}
+
+func reloadConfig() {
+	config, err := loadConfig()
+	if err != nil {
+		logger.Sugar().Errorf("Cannot reload config; keeping the current one: %v", err)
+		return
+	}
+	if changed := getConfig().RestartRequired(config); len(changed) > 0 {
+		logger.Sugar().Warnf("Changes in %v are applied after restart", changed)
+	}
+	level, _ := zapcore.ParseLevel(config.LogLevel)
+	logLevel.SetLevel(level)
+	setConfig(config)
+	logger.Sugar().Info("Config reloaded")
+}
+
 // https://discord.com/oauth2/authorize?client_id=1018084105587544166&scope=bot&permissions=10737436672
 func main() {
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && os.Getenv("IKABOT3_CONFIG") == "" {
 		log.Fatal("Error loading .env file")
 	}
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	setConfig(config)
 
-	logger, _ = zap.NewProduction()
+	level, _ := zapcore.ParseLevel(config.LogLevel)
+	logLevel.SetLevel(level)
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Level = logLevel
+	logger, _ = zapConfig.Build()
 	defer func() {
 		_ = logger.Sync()
 	}()
@@ -108,17 +180,112 @@ func main() {
 		logger.Sugar().Info(http.ListenAndServe("localhost:6060", nil))
 	}()
 
-	scheduleStore = NewScheduleStore()
+	scheduleStore = NewScheduleStore(config.Cache.Dir)
 	scheduleStore.MaybeRefresh()
+	refreshStop := make(chan struct{})
+	go scheduleStore.RefreshEvery(time.Minute, refreshStop)
+	defer close(refreshStop)
 
-	bot, err := LaunchDiscordBot(os.Getenv("IKABOT3_TOKEN"), os.Getenv("IKABOT3_ALLOW_MESSAGE_CONTENT_INTENT") == "TRUE")
-	if err != nil {
-		logger.Sugar().Errorw("bot creation failed", err)
+	core := NewBot(&scheduleStore)
+	core.PublicURL = config.HTTP.PublicURL
+	var bot *DiscordBot
+	if config.Discord.Enabled {
+		bot, err = LaunchDiscordBot(core, DiscordBotConfig{
+			Token:                     config.Discord.Token,
+			AllowMessageContentIntent: config.Discord.AllowMessageContentIntent,
+			CommandGuildID:            config.Discord.CommandGuildID,
+			DeleteCommandsOnExit:      config.Discord.DeleteCommandsOnExit,
+			Settings:                  NewGuildSettingsStore(config.Cache.Dir, "guild_settings"),
+			Banners:                   NewBannerRenderer(config.Cache.Dir),
+			BannerImages:              config.Discord.BannerImages,
+		})
+		if err != nil {
+			logger.Sugar().Errorw("bot creation failed", err)
+		}
+	}
+
+	if config.HTTP.Addr != "" {
+		httpServer := NewHTTPServer(core)
+		httpServer.Readiness.Add("schedule", func() error {
+			// probes only read caches; RefreshEvery fetches the upstream API
+			return scheduleStore.CheckFreshness(getConfig().HTTP.ReadyMaxAge)
+		})
+		if bot != nil {
+			httpServer.Liveness.Add("discord_gateway", bot.CheckGateway)
+			httpServer.Readiness.Add("discord_commands", bot.CheckCommands)
+		} else if config.Discord.Enabled {
+			httpServer.Liveness.Add("discord_gateway", func() error {
+				return errors.New("discord bot is not running")
+			})
+		}
+		go func() {
+			logger.Sugar().Info(http.ListenAndServe(config.HTTP.Addr, httpServer.Handler()))
+		}()
+	}
+
+	if slack := config.Adapters.Slack; slack.Enabled {
+		slackBot := NewSlackBot(core, SlackBotConfig{
+			SigningSecret: slack.SigningSecret,
+			BotToken:      slack.BotToken,
+		})
+		go func() {
+			logger.Sugar().Info(http.ListenAndServe(slack.ListenAddr, slackBot.Handler()))
+		}()
+	}
+
+	if matrix := config.Adapters.Matrix; matrix.Enabled {
+		matrixBot := NewMatrixBot(core, MatrixBotConfig{
+			HomeserverURL: matrix.HomeserverURL,
+			AccessToken:   matrix.AccessToken,
+			UserID:        matrix.UserID,
+			Keywords:      matrix.Keywords,
+		})
+		go matrixBot.Run()
+		defer matrixBot.Close()
+	}
+
+	if irc := config.Adapters.IRC; irc.Enabled {
+		ircBot := NewIRCBot(core, IRCBotConfig{
+			Server:   irc.Server,
+			UseTLS:   irc.UseTLS,
+			Password: irc.Password,
+			Nick:     irc.Nick,
+			Channels: irc.Channels,
+		})
+		go func() {
+			for {
+				err := ircBot.Run()
+				if errors.Is(err, errIRCClosed) {
+					return
+				}
+				logger.Sugar().Errorf("IRC connection closed: %v", err)
+				time.Sleep(time.Second * 30)
+			}
+		}()
+		defer ircBot.Close()
+	}
+
+	if webhook := config.Adapters.Webhook; webhook.Enabled {
+		webhookConfig, err := LoadWebhookConfig(webhook.File)
+		if err == nil {
+			publisher := NewWebhookPublisher(&scheduleStore, webhookConfig.Targets)
+			go publisher.Run(time.Minute)
+			defer publisher.Close()
+		} else {
+			logger.Sugar().Errorf("Cannot load webhook config %s: %v", webhook.File, err)
+		}
 	}
 
 	logger.Sugar().Info("Bot is now running.  Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	<-sc
-	bot.CloseDiscordBot()
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP)
+	for sig := range sc {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloadConfig()
+	}
+	if bot != nil {
+		bot.CloseDiscordBot()
+	}
 }
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type MatrixBot struct {
+	Core          *Bot
+	HomeserverURL string
+	AccessToken   string
+	UserID        string
+	// Keywords are additional triggers such as a display name of the bot
+	Keywords []string
+	// ListenAll treats every message as a search even without mentions
+	ListenAll bool
+	client    *http.Client
+	since     string
+	txnID     int64
+	stop      chan struct{}
+}
+
+type MatrixBotConfig struct {
+	HomeserverURL string
+	AccessToken   string
+	UserID        string
+	Keywords      []string
+	ListenAll     bool
+}
+
+type matrixEvent struct {
+	Type    string `json:"type"`
+	Sender  string `json:"sender"`
+	EventID string `json:"event_id"`
+	Content struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	} `json:"content"`
+}
+
+type matrixSyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []matrixEvent `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+type matrixMessage struct {
+	MsgType       string           `json:"msgtype"`
+	Body          string           `json:"body"`
+	Format        string           `json:"format,omitempty"`
+	FormattedBody string           `json:"formatted_body,omitempty"`
+	RelatesTo     *matrixRelatesTo `json:"m.relates_to,omitempty"`
+}
+
+type matrixRelatesTo struct {
+	InReplyTo matrixInReplyTo `json:"m.in_reply_to"`
+}
+
+type matrixInReplyTo struct {
+	EventID string `json:"event_id"`
+}
+
+func NewMatrixBot(core *Bot, config MatrixBotConfig) *MatrixBot {
+	return &MatrixBot{
+		Core:          core,
+		HomeserverURL: strings.TrimSuffix(config.HomeserverURL, "/"),
+		AccessToken:   config.AccessToken,
+		UserID:        config.UserID,
+		Keywords:      config.Keywords,
+		ListenAll:     config.ListenAll,
+		client:        &http.Client{Timeout: time.Minute},
+		stop:          make(chan struct{}),
+	}
+}
+
+func (mb *MatrixBot) request(method string, path string, body interface{}, result interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(bs)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, mb.HomeserverURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+mb.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", getConfig().UserAgent)
+	resp, err := mb.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s failed: %s", method, path, resp.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// stripMention removes the mention of the bot as a word such as "ikabot3: "; returns false if the bot is not called
+func (mb *MatrixBot) stripMention(body string) (string, bool) {
+	// Element sends a mention as a display name in the plain body
+	for _, keyword := range append([]string{mb.UserID}, mb.Keywords...) {
+		if keyword == "" {
+			continue
+		}
+		for offset := 0; ; {
+			idx := strings.Index(body[offset:], keyword)
+			if idx < 0 {
+				break
+			}
+			start, end := offset+idx, offset+idx+len(keyword)
+			before, _ := utf8.DecodeLastRuneInString(body[:start])
+			after, _ := utf8.DecodeRuneInString(body[end:])
+			if (start == 0 || unicode.IsSpace(before)) && (end == len(body) || unicode.IsSpace(after) || after == ':' || after == ',') {
+				rest := strings.TrimLeft(body[end:], ":,")
+				return strings.TrimSpace(strings.TrimSpace(body[:start]) + " " + strings.TrimSpace(rest)), true
+			}
+			offset = start + 1
+		}
+	}
+	return body, false
+}
+
+func createMatrixMessage(resp BotResponse) matrixMessage {
+	if len(resp.Cards) == 0 {
+		return matrixMessage{MsgType: "m.notice", Body: resp.Text}
+	}
+	var texts []string
+	var htmls []string
+	for _, card := range resp.Cards {
+		texts = append(texts, card.PlainText())
+		if card.NotFound {
+			htmls = append(htmls, fmt.Sprintf("<p><b>%s</b><br>%s</p>", html.EscapeString(card.ModeName), html.EscapeString(card.Description())))
+			continue
+		}
+		var lines []string
+		for _, line := range card.Lines {
+			lines = append(lines, html.EscapeString(line))
+		}
+		htmls = append(htmls, fmt.Sprintf(`<p><font color="%s"><b>%s</b></font><br><b>%s</b> %s<br>%s</p>`,
+			colorToHex(card.Color), html.EscapeString(card.ModeName),
+			html.EscapeString(card.Title), html.EscapeString(card.Period()),
+			strings.Join(lines, "<br>")))
+	}
+	return matrixMessage{
+		MsgType:       "m.notice",
+		Body:          strings.Join(texts, "\n"),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: strings.Join(htmls, ""),
+	}
+}
+
+func (mb *MatrixBot) handleEvent(roomID string, event matrixEvent) {
+	if event.Type != "m.room.message" || event.Sender == mb.UserID || event.Content.MsgType != "m.text" {
+		return
+	}
+	text, mentioned := mb.stripMention(event.Content.Body)
+	if !mentioned && !mb.ListenAll {
+		return
+	}
+	resp := mb.Core.Handle(BotRequest{Text: text, Mentioned: mentioned, Transport: "matrix"})
+	if resp.Ignored {
+		return
+	}
+	msg := createMatrixMessage(resp)
+	msg.RelatesTo = &matrixRelatesTo{InReplyTo: matrixInReplyTo{EventID: event.EventID}}
+	txnID := atomic.AddInt64(&mb.txnID, 1)
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/ikabot3-%d-%d",
+		url.PathEscape(roomID), time.Now().UnixNano(), txnID)
+	err := mb.request("PUT", path, msg, nil)
+	if err != nil {
+		logger.Sugar().Error(err)
+	}
+}
+
+// Sync processes new events once; the first sync only records the position to skip old messages
+func (mb *MatrixBot) Sync(timeout time.Duration) error {
+	params := url.Values{}
+	params.Set("timeout", fmt.Sprint(timeout.Milliseconds()))
+	if mb.since != "" {
+		params.Set("since", mb.since)
+	}
+	var resp matrixSyncResponse
+	err := mb.request("GET", "/_matrix/client/v3/sync?"+params.Encode(), nil, &resp)
+	if err != nil {
+		return err
+	}
+	initial := mb.since == ""
+	mb.since = resp.NextBatch
+	if initial {
+		return nil
+	}
+	for roomID, room := range resp.Rooms.Join {
+		for _, event := range room.Timeline.Events {
+			mb.handleEvent(roomID, event)
+		}
+	}
+	return nil
+}
+
+func (mb *MatrixBot) Run() {
+	for {
+		select {
+		case <-mb.stop:
+			return
+		default:
+		}
+		err := mb.Sync(time.Second * 30)
+		if err != nil {
+			logger.Sugar().Errorf("Matrix sync failed: %v", err)
+			time.Sleep(time.Second * 10)
+		}
+	}
+}
+
+func (mb *MatrixBot) Close() {
+	close(mb.stop)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHomeserver returns queued timelines from /sync and records sent messages
+type fakeHomeserver struct {
+	sync.Mutex
+	batches [][]matrixEvent
+	sent    []matrixMessage
+}
+
+func (fh *fakeHomeserver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fh.Lock()
+	defer fh.Unlock()
+	if r.Header.Get("Authorization") != "Bearer syt_test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/_matrix/client/v3/sync":
+		var events []matrixEvent
+		if r.URL.Query().Get("since") != "" && len(fh.batches) > 0 {
+			events, fh.batches = fh.batches[0], fh.batches[1:]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"next_batch": "s" + r.URL.Query().Get("since") + "1",
+			"rooms": map[string]interface{}{
+				"join": map[string]interface{}{
+					"!squad:example.org": map[string]interface{}{
+						"timeline": map[string]interface{}{"events": events},
+					},
+				},
+			},
+		})
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/_matrix/client/v3/rooms/!squad:example.org/send/m.room.message/"):
+		var msg matrixMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		fh.sent = append(fh.sent, msg)
+		_, _ = w.Write([]byte(`{"event_id":"$sent"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestMatrixEvent(eventID string, sender string, body string) matrixEvent {
+	event := matrixEvent{Type: "m.room.message", Sender: sender, EventID: eventID}
+	event.Content.MsgType = "m.text"
+	event.Content.Body = body
+	return event
+}
+
+func TestMatrixBot_stripMention(t *testing.T) {
+	mb := &MatrixBot{UserID: "@ikabot3:example.org", Keywords: []string{"ikabot3", "イカボット"}}
+	tests := []struct {
+		name          string
+		args          string
+		want          string
+		wantMentioned bool
+	}{
+		{name: "leading display name must be removed", args: "ikabot3: 次のガチマ", want: "次のガチマ", wantMentioned: true},
+		{name: "user ID must be removed", args: "@ikabot3:example.org 次のガチマ", want: "次のガチマ", wantMentioned: true},
+		{name: "keyword in the middle must be removed once", args: "次のガチマ イカボット", want: "次のガチマ", wantMentioned: true},
+		{name: "keyword in another word must not be a mention", args: "ikabot3000 次のガチマ", want: "ikabot3000 次のガチマ", wantMentioned: false},
+		{name: "keyword at the end of another word must not be a mention", args: "myikabot3: 次のガチマ", want: "myikabot3: 次のガチマ", wantMentioned: false},
+		{name: "only the mention must be removed", args: "ikabot3, ikabot3000 って何", want: "ikabot3000 って何", wantMentioned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, mentioned := mb.stripMention(tt.args)
+			if got != tt.want || mentioned != tt.wantMentioned {
+				t.Errorf("stripMention() = %v, %v, want %v, %v", got, mentioned, tt.want, tt.wantMentioned)
+			}
+		})
+	}
+}
+
+func TestMatrixBot_Sync(t *testing.T) {
+	fh := &fakeHomeserver{
+		batches: [][]matrixEvent{
+			{
+				newTestMatrixEvent("$1", "@alice:example.org", "ikabot3: 次のガチマ"),
+				newTestMatrixEvent("$2", "@alice:example.org", "次のガチマ"),
+				newTestMatrixEvent("$3", "@ikabot3:example.org", "ikabot3: ガチマ"),
+			},
+		},
+	}
+	server := httptest.NewServer(fh)
+	defer server.Close()
+
+	mb := NewMatrixBot(NewBot(newTestStore()), MatrixBotConfig{
+		HomeserverURL: server.URL,
+		AccessToken:   "syt_test",
+		UserID:        "@ikabot3:example.org",
+		Keywords:      []string{"ikabot3"},
+	})
+	// the first sync skips old messages
+	for i := 0; i < 2; i++ {
+		err := mb.Sync(time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fh.Lock()
+	defer fh.Unlock()
+	if len(fh.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1: %#v", len(fh.sent), fh.sent)
+	}
+	msg := fh.sent[0]
+	if msg.RelatesTo == nil || msg.RelatesTo.InReplyTo.EventID != "$1" {
+		t.Errorf("reply target = %#v", msg.RelatesTo)
+	}
+	if !strings.Contains(msg.Body, "ガチヤグラ") || !strings.Contains(msg.Body, "ナメロウ金属") {
+		t.Errorf("body = %v", msg.Body)
+	}
+	if msg.Format != "org.matrix.custom.html" || !strings.Contains(msg.FormattedBody, `<font color="#f64a10">`) {
+		t.Errorf("formatted body = %v", msg.FormattedBody)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metrics is a registry of the bot; collectors of the Go runtime are not included
+var metrics = prometheus.NewRegistry()
+
+func metricsHandler() http.Handler {
+	return promhttp.HandlerFor(metrics, promhttp.HandlerOpts{})
+}
+
+// refreshAgeCollector computes seconds since the last successful refresh on each scrape
+type refreshAgeCollector struct {
+	sync.Mutex
+	desc      *prometheus.Desc
+	refreshed map[string]time.Time
+}
+
+func (rc *refreshAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rc.desc
+}
+
+func (rc *refreshAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	rc.Lock()
+	defer rc.Unlock()
+	for source, refreshed := range rc.refreshed {
+		ch <- prometheus.MustNewConstMetric(rc.desc, prometheus.GaugeValue, time.Since(refreshed).Seconds(), source)
+	}
+}
+
+// recordRefresh sets the time of the last successful refresh of the source
+func recordRefresh(source string, refreshed time.Time) {
+	metricsLastRefresh.WithLabelValues(source).Set(float64(refreshed.Unix()))
+	metricsRefreshAge.Lock()
+	defer metricsRefreshAge.Unlock()
+	metricsRefreshAge.refreshed[source] = refreshed
+}
+
+var (
+	metricsRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ikabot3_requests_total",
+		Help: "Number of requests handled by the bot.",
+	}, []string{"transport", "type"})
+	metricsParseTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ikabot3_parse_total",
+		Help: "Number of keyword inputs parsed.",
+	}, []string{"mode", "result"})
+	metricsSearchTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ikabot3_search_total",
+		Help: "Number of searches to the schedule store.",
+	}, []string{"mode", "result"})
+	metricsUpstreamFetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ikabot3_upstream_fetch_duration_seconds",
+		Help:    "Latency of fetching the upstream API.",
+		Buckets: DefaultLatencyBuckets,
+	}, []string{"source"})
+	metricsUpstreamFetchErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ikabot3_upstream_fetch_errors_total",
+		Help: "Number of errors on fetching the upstream API.",
+	}, []string{"source"})
+	metricsFileCacheTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ikabot3_file_cache_requests_total",
+		Help: "Number of lookups to file caches.",
+	}, []string{"cache", "result"})
+	metricsDiscordAPIErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ikabot3_discord_api_errors_total",
+		Help: "Number of errors returned by the Discord API.",
+	}, []string{"operation"})
+	metricsLastRefresh = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ikabot3_last_refresh_timestamp_seconds",
+		Help: "Unix time of the last successful refresh of schedules.",
+	}, []string{"source"})
+	metricsRefreshAge = &refreshAgeCollector{
+		desc: prometheus.NewDesc("ikabot3_seconds_since_last_refresh",
+			"Seconds since the last successful refresh of schedules.", []string{"source"}, nil),
+		refreshed: map[string]time.Time{},
+	}
+)
+
+func init() {
+	metrics.MustRegister(
+		metricsRequestsTotal,
+		metricsParseTotal,
+		metricsSearchTotal,
+		metricsUpstreamFetchDuration,
+		metricsUpstreamFetchErrorsTotal,
+		metricsFileCacheTotal,
+		metricsDiscordAPIErrorsTotal,
+		metricsLastRefresh,
+		metricsRefreshAge,
+	)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordRefresh(t *testing.T) {
+	refreshed := time.Now().Add(-time.Minute)
+	recordRefresh("test", refreshed)
+	if value := testutil.ToFloat64(metricsLastRefresh.WithLabelValues("test")); value != float64(refreshed.Unix()) {
+		t.Errorf("last refresh must be a minute ago; got %v", value)
+	}
+	rec := httptest.NewRecorder()
+	metricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `ikabot3_seconds_since_last_refresh{source="test"} 60`) {
+		t.Errorf("seconds since last refresh must be exposed; got %v", rec.Body.String())
+	}
+}
+
+func TestHTTPServer_Metrics(t *testing.T) {
+	core := NewBot(newTestStore())
+	failures := testutil.ToFloat64(metricsParseTotal.WithLabelValues("", "failure"))
+	core.Handle(BotRequest{Text: "次のガチマ", Transport: "test"})
+	core.Handle(BotRequest{Text: "こんにちは", Transport: "test"})
+	if value := testutil.ToFloat64(metricsParseTotal.WithLabelValues("", "failure")); value != failures {
+		t.Errorf("unrelated chats must not be counted as parse failures; got %v, want %v", value, failures)
+	}
+	core.Handle(BotRequest{Text: "こんにちは", Transport: "test", Mentioned: true})
+	if value := testutil.ToFloat64(metricsParseTotal.WithLabelValues("", "failure")); value != failures+1 {
+		t.Errorf("mentioned inputs must be counted as parse failures; got %v, want %v", value, failures+1)
+	}
+
+	rec := httptest.NewRecorder()
+	NewHTTPServer(core).Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`ikabot3_requests_total{transport="test",type="text"} 3`,
+		`ikabot3_parse_total{mode="CHALLENGE",result="success"}`,
+		`ikabot3_parse_total{mode="",result="failure"}`,
+		`ikabot3_search_total{mode="CHALLENGE",result="found"}`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics must contain %v; got %v", line, body)
+		}
+	}
+}
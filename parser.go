@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 type SearchQuery struct {
@@ -13,12 +16,16 @@ type SearchQuery struct {
 	// XXX: double-meaning game mode and search mode; allows pseudo mode here
 	Mode Mode
 	Rule string
+	// Language is LocaleEN if given in English keywords
+	Language string
+	// Question is given by question forms such as いつまで; see QuestionWhen
+	Question string
 }
 
 // <command> := [前の|次の]+<type> | <type><time>
 // <type> := <rule> | <mode>
 // <mode> := ナワバリ[バトル]? | [ガチ|オープン|チャレンジ][マッチ]?
-// <time> := 0, 1, ..., 24
+// <time> := [午前|午後|朝|昼|夕方|夜]?<hour>[時|:MM] where <hour> is 0, 1, ..., 29 in digits or kanji
 
 func countRelativeIdentifier(input string) (result int) {
 	next := strings.Count(input, IDENTIFIER_NEXT)
@@ -80,7 +87,164 @@ func searchRuleIdentifier(input string) string {
 	return ""
 }
 
+// NormalizeInput removes spaces and mention syntax from the input passed to Parse;
+// NFKC folds full-width and half-width characters such as ﾁｬﾚﾝｼﾞ and １９
+func NormalizeInput(input string) string {
+	// remove mention syntax
+	regex := regexp.MustCompile(` *<@&?\d+?> *`)
+	input = norm.NFKC.String(regex.ReplaceAllString(input, ""))
+	// remove spaces
+	return strings.ReplaceAll(input, " ", "")
+}
+
+// japaneseQuestions map question forms at the end of the input to questions
+var japaneseQuestions = regexp.MustCompile(`は?(いつから|いつまで|いつ|何時から|何時まで|あと何分|あと何時間|あとどれくらい)[?？]*$`)
+
+// trimQuestion removes the question form and reports the question if any
+func trimQuestion(input string) (string, string) {
+	fss := japaneseQuestions.FindStringSubmatch(input)
+	if fss == nil {
+		return input, ""
+	}
+	question := QuestionWhen
+	switch fss[1] {
+	case "いつまで", "何時まで":
+		question = QuestionUntil
+	case "あと何分", "あと何時間", "あとどれくらい":
+		question = QuestionRemaining
+	}
+	return strings.TrimSuffix(input, fss[0]), question
+}
+
+// kanjiNumerals map kanji digits to values; 十 is handled separately
+var kanjiNumerals = map[rune]int{
+	'〇': 0, '零': 0, '一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// kanjiToNumber converts kanji numerals such as 十九, 二十五 and 一九 into the value
+func kanjiToNumber(input string) (int, bool) {
+	if tens, ones, found := strings.Cut(input, "十"); found {
+		t, o := 1, 0
+		var ok bool
+		if tens != "" {
+			if t, ok = kanjiToNumber(tens); !ok || t > 9 {
+				return 0, false
+			}
+		}
+		if ones != "" {
+			if o, ok = kanjiToNumber(ones); !ok || o > 9 {
+				return 0, false
+			}
+		}
+		return t*10 + o, true
+	}
+	value := 0
+	for _, r := range input {
+		digit, found := kanjiNumerals[r]
+		if !found {
+			return 0, false
+		}
+		value = value*10 + digit
+	}
+	return value, input != ""
+}
+
+// adjustHour applies modifiers such as 午後, 夜 and pm to the hour on a 12-hour clock
+func adjustHour(hour int, modifier string) int {
+	switch modifier {
+	case "午前", "朝", "am":
+		if hour == 12 {
+			return 0
+		}
+	case "午後", "夕方", "pm":
+		if hour < 12 {
+			return hour + 12
+		}
+	case "昼":
+		// 昼1時 is 13 時 while 昼11時 is 11 時
+		if hour < 6 {
+			return hour + 12
+		}
+	case "夜":
+		// 夜1時 is past midnight
+		if hour >= 6 && hour <= 12 {
+			return hour + 12
+		}
+	}
+	return hour
+}
+
+var (
+	kanjiHour      = regexp.MustCompile(`([〇零一二三四五六七八九十]+) ?(時|:)`)
+	timeExpression = regexp.MustCompile(`(午前|午後|朝|昼|夕方|夜)?(\d{1,2})( ?)(?::\d{2}|時(?:半|\d{1,2}分)?)`)
+)
+
+// normalizeTimes rewrites time expressions such as 十九時, 午後7時 and 19:00 into the form of 19時;
+// minutes are dropped since every slot starts on the hour, and hours over 24 such as 25時 are left for search
+func normalizeTimes(input string) string {
+	input = kanjiHour.ReplaceAllStringFunc(input, func(match string) string {
+		fss := kanjiHour.FindStringSubmatch(match)
+		value, ok := kanjiToNumber(fss[1])
+		if !ok {
+			return match
+		}
+		return strconv.Itoa(value) + fss[2]
+	})
+	return timeExpression.ReplaceAllStringFunc(input, func(match string) string {
+		fss := timeExpression.FindStringSubmatch(match)
+		hour, err := strconv.Atoi(fss[2])
+		if err != nil {
+			return match
+		}
+		return strconv.Itoa(adjustHour(hour, fss[1])) + fss[3] + "時"
+	})
+}
+
+// modeParticle allows の between modes and rules such as Xのアサリ
+var modeParticle = regexp.MustCompile(`(マッチ|ガチマ|リグマ|バカマ|レギュラー|リーグ|バンカラ|オープン|チャレンジ|エックス|[Xx])の((ガチ)?(ナワバリ|エリア|ホコ|ヤグラ|アサリ))`)
+
+// normalizeKeywords rewrites times and keywords into the forms of the grammar in Parse;
+// loose also folds kana and typos of keywords, which is too eager for chats not calling the bot
+func normalizeKeywords(input string, loose bool) string {
+	input = normalizeTimes(input)
+	if loose {
+		input = foldKeywords(input)
+	}
+	return modeParticle.ReplaceAllString(input, "$1$2")
+}
+
+// parseOverview accepts keywords of the overview only as a whole input since 今 and 全部 are common words
+func parseOverview(input string) *SearchQuery {
+	regex := regexp.MustCompile(`^((次の|前の)*)(今|全部)$`)
+	fss := regex.FindStringSubmatch(input)
+	if fss == nil {
+		return nil
+	}
+	var rindex string
+	if fss[1] != "" {
+		rindex = strconv.Itoa(countRelativeIdentifier(fss[1]))
+	}
+	return &SearchQuery{
+		OriginalText:  fss[0],
+		RelativeIndex: rindex,
+		Mode:          getMode("ALL"),
+	}
+}
+
 func Parse(input string) *SearchQuery {
+	query, _, _ := parseClause(input, false)
+	return query
+}
+
+// ParseLoose is Parse which also accepts keywords in hiragana and with typos such as ちゃれんじ and ヤグラー;
+// use it only for inputs explicitly given to the bot
+func ParseLoose(input string) *SearchQuery {
+	query, _, _ := parseClause(input, true)
+	return query
+}
+
+// parseClause is Parse which also returns the text before the match and the normalized text given to the grammar
+func parseClause(input string, loose bool) (query *SearchQuery, leading string, normalized string) {
 	/*
 	   次の次の前の次の次のガチマッチ
 	   ガチマ
@@ -93,10 +257,27 @@ func Parse(input string) *SearchQuery {
 	   19 時のガチマッチ
 	   ガチマ 20
 	   次のエリア
+	   今
+	   次の全部
+	   次のエリアいつ？
+	   Xのアサリはいつまで？
+	   午後7時のガチマ
+	   ガチマ19:00
 	*/
-	regex := regexp.MustCompile(`(((次の|前の)*)((\d{0,2}) ?時の)?((ガチマッチ|ガチマ|ガチ|リグマ|バカマ|(レギュラー|リーグ|バンカラ|オープン|チャレンジ|エックス|[Xx] ?)(マッチ)?)?(ガチ)?(ナワバリ|ナワバリバトル|エリア|ホコ|ホコバトル|ヤグラ|アサリ)?|シャケ|サーモン|サーモンラン|鮭) ?(\d{0,2}))$`)
+	input, question := trimQuestion(input)
+	input = normalizeKeywords(input, loose)
+	if query := parseOverview(input); query != nil {
+		query.Question = question
+		return query, "", input
+	}
+	regex := regexp.MustCompile(`(((次の|前の)*)((\d{0,2}) ?時の?)?((ガチマッチ|ガチマ|ガチ|リグマ|バカマ|(レギュラー|リーグ|バンカラ|オープン|チャレンジ|エックス|[Xx] ?)(マッチ)?)?(ガチ)?(ナワバリ|ナワバリバトル|エリア|ホコ|ホコバトル|ヤグラ|アサリ)?|シャケ|サーモン|サーモンラン|鮭) ?(\d{0,2})時?)$`)
 	fss := regex.FindStringSubmatch(input)
 	logger.Sugar().Infof("keyword input: %#v", fss)
+	// times such as 今何時 and 明日10時 are not keywords without modes or rules unless given as 19時の for the default mode
+	timePrefixed := fss[5] != "" && strings.HasSuffix(fss[4], "の")
+	if fss[6] == "" && !timePrefixed && fss[0] != fss[2] {
+		return &SearchQuery{Mode: getMode(""), Question: question}, input, input
+	}
 	var timeIndex string
 	if fss[5] != "" {
 		timeIndex = fss[5]
@@ -117,5 +298,228 @@ func Parse(input string) *SearchQuery {
 		TimeIndex:     timeIndex,
 		Mode:          getMode(searchModeIdentifier(fss[6])),
 		Rule:          searchRuleIdentifier(fss[11]),
+		Question:      question,
+	}, strings.TrimSuffix(input, fss[0]), input
+}
+
+// maxQueryClauses keeps replies within 10 embeds of Discord even if every clause is a rule for 3 modes
+const maxQueryClauses = 3
+
+var clauseSeparator = regexp.MustCompile(`[と、,]`)
+
+// errTooManyQueries is returned if every clause is a query but there are more than maxQueryClauses
+var errTooManyQueries = errors.New("too many queries")
+
+// sharedRuleModes are modes which a rule of the last clause is shared with such as オープンとXのヤグラ
+var sharedRuleModes = map[string]bool{"BANKARA": true, "CHALLENGE": true, "OPEN": true, "X": true}
+
+// ParseAll parses clauses joined by と and 、 such as 次のガチマと次のシャケ;
+// it falls back to Parse unless every clause is a query, and the question applies to all clauses.
+// too many clauses result in a query matching nothing
+func ParseAll(input string) []*SearchQuery {
+	queries, _, _ := parseAll(input, false)
+	return queries
+}
+
+// ParseAllLoose is ParseAll with ParseLoose for inputs explicitly given to the bot
+func ParseAllLoose(input string) []*SearchQuery {
+	queries, _, _ := parseAll(input, true)
+	return queries
+}
+
+// parseAll also returns the normalized clauses given to the grammar for explanations
+func parseAll(input string, loose bool) ([]*SearchQuery, []string, error) {
+	query, _, normalized := parseClause(input, loose)
+	fallback, fallbackNormalized := []*SearchQuery{query}, []string{normalized}
+	trimmed, question := trimQuestion(input)
+	clauses := clauseSeparator.Split(trimmed, -1)
+	if len(clauses) < 2 {
+		return fallback, fallbackNormalized, nil
+	}
+	var queries []*SearchQuery
+	var normalizedClauses []string
+	for idx, clause := range clauses {
+		query, leading, normalized := parseClause(clause, loose)
+		// only the first clause may follow other words as Parse does; overviews are already all modes
+		if query.OriginalText == "" || (idx > 0 && leading != "") || query.Mode.getIdentifier() == "ALL" {
+			return fallback, fallbackNormalized, nil
+		}
+		query.Question = question
+		queries = append(queries, query)
+		normalizedClauses = append(normalizedClauses, normalized)
+	}
+	// answering only the last clause as the fallback does would look like the others were not found
+	if len(queries) > maxQueryClauses {
+		return []*SearchQuery{{Mode: getMode(""), Question: question}}, normalizedClauses, errTooManyQueries
+	}
+	last := queries[len(queries)-1]
+	if last.Rule != "" && sharedRuleModes[last.Mode.getIdentifier()] {
+		for _, query := range queries[:len(queries)-1] {
+			if query.Rule == "" && sharedRuleModes[query.Mode.getIdentifier()] {
+				query.Rule = last.Rule
+			}
+		}
+	}
+	return queries, normalizedClauses, nil
+}
+
+// englishModes and englishRules map English keywords to identifiers; longer keywords come first
+// and words common in chats such as x and open are not accepted alone
+var englishModes = []struct{ keyword, mode string }{
+	{"anarchy battle (series)", "CHALLENGE"},
+	{"anarchy battle (open)", "OPEN"},
+	{"anarchy series", "CHALLENGE"},
+	{"anarchy open", "OPEN"},
+	{"anarchy battle", "BANKARA"},
+	{"anarchy", "BANKARA"},
+	{"x battle", "X"},
+	{"x match", "X"},
+	{"regular battle", "REGULAR"},
+	{"regular", "REGULAR"},
+	{"salmon run", "SALMON"},
+	{"salmon", "SALMON"},
+	{"grizzco", "SALMON"},
+	{"all modes", "ALL"},
+	{"all", "ALL"},
+	{"now", "ALL"},
+}
+
+var englishRules = []struct{ keyword, rule string }{
+	{"turf war", "TURF_WAR"},
+	{"splat zones", "AREA"},
+	{"tower control", "LOFT"},
+	{"rainmaker", "GOAL"},
+	{"clam blitz", "CLAM"},
+	{"clams", "CLAM"},
+}
+
+// englishQuestions are accepted at the beginning of English keywords
+var englishQuestions = []struct{ keyword, question string }{
+	{"until when is", QuestionUntil},
+	{"until when", QuestionUntil},
+	{"when is", QuestionWhen},
+	{"when", QuestionWhen},
+	{"how long until", QuestionRemaining},
+	{"how long is", QuestionRemaining},
+	{"how long", QuestionRemaining},
+}
+
+func trimEnglishKeyword(input string, keyword string) (string, bool) {
+	if input == keyword {
+		return "", true
+	}
+	if strings.HasPrefix(input, keyword+" ") {
+		return strings.TrimPrefix(input, keyword+" "), true
+	}
+	return input, false
+}
+
+var englishClauseSeparator = regexp.MustCompile(`(?i) *(?:,|\band\b) *`)
+
+// ParseAllEnglish parses clauses of ParseEnglish joined by "and" and commas;
+// it returns nil unless every clause is understood, and the question of the first clause applies to all
+func ParseAllEnglish(input string) []*SearchQuery {
+	clauses := englishClauseSeparator.Split(input, -1)
+	if len(clauses) > maxQueryClauses {
+		return nil
+	}
+	var queries []*SearchQuery
+	for _, clause := range clauses {
+		query := ParseEnglish(clause)
+		if query == nil || (len(clauses) > 1 && query.Mode.getIdentifier() == "ALL") {
+			return nil
+		}
+		if len(queries) > 0 {
+			query.Question = queries[0].Question
+		}
+		queries = append(queries, query)
+	}
+	return queries
+}
+
+// ParseEnglish parses English keywords such as "next splat zones", "x match 19" and "salmon run";
+// it returns nil unless the whole input is understood
+func ParseEnglish(input string) *SearchQuery {
+	regex := regexp.MustCompile(` *<@&?\d+?> *`)
+	text := strings.ToLower(strings.Join(strings.Fields(regex.ReplaceAllString(input, " ")), " "))
+	text = strings.TrimSpace(strings.TrimRight(text, "?"))
+	rest := text
+
+	var question string
+	for _, q := range englishQuestions {
+		if trimmed, found := trimEnglishKeyword(rest, q.keyword); found {
+			rest, question = trimmed, q.question
+			break
+		}
+	}
+
+	relative := 0
+	hasRelative := false
+	for {
+		var found bool
+		for _, keyword := range []string{"next", "prev", "previous"} {
+			if rest, found = trimEnglishKeyword(rest, keyword); found {
+				hasRelative = true
+				if keyword == "next" {
+					relative += 1
+				} else {
+					relative -= 1
+				}
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	var mode, rule string
+	for _, m := range englishModes {
+		if trimmed, found := trimEnglishKeyword(rest, m.keyword); found {
+			rest, mode = trimmed, m.mode
+			break
+		}
+	}
+	for _, r := range englishRules {
+		if trimmed, found := trimEnglishKeyword(rest, r.keyword); found {
+			rest, rule = trimmed, r.rule
+			break
+		}
+	}
+	if mode == "" && rule == "" {
+		return nil
+	}
+	if mode == "" {
+		if rule == "TURF_WAR" {
+			mode = "REGULAR"
+		} else {
+			mode = "BYRULE"
+		}
+	}
+
+	var timeIndex string
+	if rest != "" {
+		hour := regexp.MustCompile(`^(?:at )?(\d{1,2})(?::\d{2}| ?o'clock| ?(am|pm))?$`).FindStringSubmatch(rest)
+		if hour == nil {
+			return nil
+		}
+		timeIndex = hour[1]
+		if hour[2] != "" {
+			value, _ := strconv.Atoi(hour[1])
+			timeIndex = strconv.Itoa(adjustHour(value, hour[2]))
+		}
+	}
+	var rindex string
+	if hasRelative {
+		rindex = strconv.Itoa(relative)
+	}
+	return &SearchQuery{
+		OriginalText:  text,
+		RelativeIndex: rindex,
+		TimeIndex:     timeIndex,
+		Mode:          getMode(mode),
+		Rule:          rule,
+		Language:      LocaleEN,
+		Question:      question,
 	}
 }
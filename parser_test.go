@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -321,6 +322,53 @@ func TestParse(t *testing.T) {
 				Rule:          "",
 			},
 		},
+		{
+			name: "今 must be proceed as ALL",
+			args: "今",
+			want: &SearchQuery{
+				OriginalText: "今",
+				Mode:         getMode("ALL"),
+			},
+		},
+		{
+			name: "次の全部 must be proceed as ALL",
+			args: "次の全部",
+			want: &SearchQuery{
+				OriginalText:  "次の全部",
+				RelativeIndex: "1",
+				Mode:          getMode("ALL"),
+			},
+		},
+		{
+			name: "次のエリアいつ？ must be proceed as a question",
+			args: "次のエリアいつ？",
+			want: &SearchQuery{
+				OriginalText:  "次のエリア",
+				RelativeIndex: "1",
+				Mode:          getMode("BYRULE"),
+				Rule:          "AREA",
+				Question:      QuestionWhen,
+			},
+		},
+		{
+			name: "Xのアサリはいつまで？ must be proceed as a question",
+			args: "Xのアサリはいつまで？",
+			want: &SearchQuery{
+				OriginalText: "Xアサリ",
+				Mode:         getMode("X"),
+				Rule:         "CLAM",
+				Question:     QuestionUntil,
+			},
+		},
+		{
+			name: "ガチマあと何分 must be proceed as a question",
+			args: "ガチマあと何分",
+			want: &SearchQuery{
+				OriginalText: "ガチマ",
+				Mode:         getMode("CHALLENGE"),
+				Question:     QuestionRemaining,
+			},
+		},
 		{
 			name: "次のガチマ",
 			args: "次のガチマ",
@@ -409,6 +457,39 @@ func TestParse(t *testing.T) {
 				Rule:          "",
 			},
 		},
+		{
+			name: "午後7時のガチマ",
+			args: "午後7時のガチマ",
+			want: &SearchQuery{
+				OriginalText:  "19時のガチマ",
+				RelativeIndex: "",
+				TimeIndex:     "19",
+				Mode:          getMode("CHALLENGE"),
+				Rule:          "",
+			},
+		},
+		{
+			name: "ガチマ19:00",
+			args: "ガチマ19:00",
+			want: &SearchQuery{
+				OriginalText:  "ガチマ19時",
+				RelativeIndex: "",
+				TimeIndex:     "19",
+				Mode:          getMode("CHALLENGE"),
+				Rule:          "",
+			},
+		},
+		{
+			name: "二十五時のエリア",
+			args: "二十五時のエリア",
+			want: &SearchQuery{
+				OriginalText:  "25時のエリア",
+				RelativeIndex: "",
+				TimeIndex:     "25",
+				Mode:          getMode("BYRULE"),
+				Rule:          "AREA",
+			},
+		},
 		{
 			name: "次のエリア",
 			args: "次のエリア",
@@ -530,6 +611,31 @@ func TestParse(t *testing.T) {
 				Rule:          "AREA",
 			},
 		},
+		{
+			name: "今何時 must not be proceed",
+			args: "今何時",
+			want: &SearchQuery{Mode: getMode("")},
+		},
+		{
+			name: "明日10時 must not be proceed without modes",
+			args: "明日10時",
+			want: &SearchQuery{Mode: getMode("")},
+		},
+		{
+			name: "bare digits must not be proceed without modes",
+			args: "20",
+			want: &SearchQuery{Mode: getMode("")},
+		},
+		{
+			name: "次の10時 must not be proceed without modes",
+			args: "次の10時",
+			want: &SearchQuery{Mode: getMode("")},
+		},
+		{
+			name: "19時の must be proceed for the default mode",
+			args: "19時の",
+			want: &SearchQuery{OriginalText: "19時の", TimeIndex: "19", Mode: getMode("")},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -539,3 +645,337 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func Test_normalizeTimes(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{name: "kanji numerals must be digits", args: "十九時のガチマ", want: "19時のガチマ"},
+		{name: "kanji digits must be digits", args: "一九時のガチマ", want: "19時のガチマ"},
+		{name: "午後 must add 12 hours", args: "午後7時のガチマ", want: "19時のガチマ"},
+		{name: "午前12時 must be 0", args: "午前12時のガチマ", want: "0時のガチマ"},
+		{name: "夜 must add 12 hours in the evening", args: "夜九時のガチマ", want: "21時のガチマ"},
+		{name: "夜 must be kept past midnight", args: "夜1時のガチマ", want: "1時のガチマ"},
+		{name: "HH:MM must be hours", args: "ガチマ19:30", want: "ガチマ19時"},
+		{name: "minutes must be dropped", args: "7時半のガチマ", want: "7時のガチマ"},
+		{name: "hours over 24 must be kept", args: "25時のガチマ", want: "25時のガチマ"},
+		{name: "spaces must be kept", args: "19 時のガチマ", want: "19 時のガチマ"},
+		{name: "text without times must be kept", args: "次のガチマ", want: "次のガチマ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeTimes(tt.args); got != tt.want {
+				t.Errorf("normalizeTimes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeInput(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{
+			name: "mention must be removed",
+			args: "<@1018084105587544166> 次のガチマ",
+			want: "次のガチマ",
+		},
+		{
+			name: "role mention must be removed",
+			args: "<@&1018084105587544166> ガチマ",
+			want: "ガチマ",
+		},
+		{
+			name: "half-width katakana must be folded",
+			args: "ﾁｬﾚﾝｼﾞ",
+			want: "チャレンジ",
+		},
+		{
+			name: "full-width digits and spaces must be folded",
+			args: "１９時の　ガチマ",
+			want: "19時のガチマ",
+		},
+		{
+			name: "spaces must be removed",
+			args: "19 時の ガチマッチ",
+			want: "19時のガチマッチ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeInput(tt.args); got != tt.want {
+				t.Errorf("NormalizeInput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnglish(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want *SearchQuery
+	}{
+		{
+			name: "next splat zones must be proceed as BYRULE",
+			args: "next splat zones",
+			want: &SearchQuery{OriginalText: "next splat zones", RelativeIndex: "1", Mode: getMode("BYRULE"), Rule: "AREA", Language: LocaleEN},
+		},
+		{
+			name: "x match 19 must be proceed as X at 19",
+			args: "X Match 19",
+			want: &SearchQuery{OriginalText: "x match 19", TimeIndex: "19", Mode: getMode("X"), Language: LocaleEN},
+		},
+		{
+			name: "x match at 7 pm must be proceed as X at 19",
+			args: "x match at 7 pm",
+			want: &SearchQuery{OriginalText: "x match at 7 pm", TimeIndex: "19", Mode: getMode("X"), Language: LocaleEN},
+		},
+		{
+			name: "salmon run with mention must be proceed as SALMON",
+			args: "<@1018084105587544166> salmon run",
+			want: &SearchQuery{OriginalText: "salmon run", Mode: getMode("SALMON"), Language: LocaleEN},
+		},
+		{
+			name: "next next prev anarchy series tower control must be proceed as 1",
+			args: "next next prev anarchy series tower control",
+			want: &SearchQuery{OriginalText: "next next prev anarchy series tower control", RelativeIndex: "1", Mode: getMode("CHALLENGE"), Rule: "LOFT", Language: LocaleEN},
+		},
+		{
+			name: "turf war at 7 must be proceed as REGULAR",
+			args: "turf war at 7",
+			want: &SearchQuery{OriginalText: "turf war at 7", TimeIndex: "7", Mode: getMode("REGULAR"), Rule: "TURF_WAR", Language: LocaleEN},
+		},
+		{
+			name: "next all must be proceed as ALL",
+			args: "next all",
+			want: &SearchQuery{OriginalText: "next all", RelativeIndex: "1", Mode: getMode("ALL"), Language: LocaleEN},
+		},
+		{
+			name: "when is next splat zones? must be proceed as a question",
+			args: "When is next Splat Zones?",
+			want: &SearchQuery{OriginalText: "when is next splat zones", RelativeIndex: "1", Mode: getMode("BYRULE"), Rule: "AREA", Language: LocaleEN, Question: QuestionWhen},
+		},
+		{
+			name: "sentences must not be proceed",
+			args: "open the door",
+			want: nil,
+		},
+		{
+			name: "bare next must not be proceed",
+			args: "next",
+			want: nil,
+		},
+		{
+			name: "x alone must not be proceed",
+			args: "x",
+			want: nil,
+		},
+		{
+			name: "open alone must not be proceed",
+			args: "open",
+			want: nil,
+		},
+		{
+			name: "next series must not be proceed without anarchy",
+			args: "next series",
+			want: nil,
+		},
+		{
+			name: "tower alone must not be proceed without control",
+			args: "tower",
+			want: nil,
+		},
+		{
+			name: "Japanese keywords must not be proceed",
+			args: "次のガチマ",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseEnglish(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEnglish() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLoose(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       string
+		want       *SearchQuery
+		wantStrict string
+	}{
+		{
+			name:       "ちゃれんじ must be proceed as CHALLENGE",
+			args:       "ちゃれんじ",
+			want:       &SearchQuery{OriginalText: "チャレンジ", Mode: getMode("CHALLENGE")},
+			wantStrict: "",
+		},
+		{
+			name:       "次のヤグラー must be proceed as LOFT",
+			args:       "次のヤグラー",
+			want:       &SearchQuery{OriginalText: "次のヤグラ", RelativeIndex: "1", Mode: getMode("BYRULE"), Rule: "LOFT"},
+			wantStrict: "",
+		},
+		{
+			name:       "しゃけ at the end of chats must be proceed as SALMON only if loose",
+			args:       "今日の晩ごはんはしゃけ",
+			want:       &SearchQuery{OriginalText: "シャケ", Mode: getMode("SALMON")},
+			wantStrict: "",
+		},
+		{
+			name:       "katakana keywords must be proceed in both",
+			args:       "次のガチマ",
+			want:       &SearchQuery{OriginalText: "次のガチマ", RelativeIndex: "1", Mode: getMode("CHALLENGE")},
+			wantStrict: "次のガチマ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseLoose(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLoose() = %v, want %v", got, tt.want)
+			}
+			if got := Parse(tt.args); got.OriginalText != tt.wantStrict {
+				t.Errorf("Parse().OriginalText = %v, want %v", got.OriginalText, tt.wantStrict)
+			}
+		})
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  string
+		loose bool
+		want  []*SearchQuery
+	}{
+		{
+			name: "clauses joined by と must be parsed respectively",
+			args: "次のガチマと次のシャケ",
+			want: []*SearchQuery{
+				{OriginalText: "次のガチマ", RelativeIndex: "1", Mode: getMode("CHALLENGE")},
+				{OriginalText: "次のシャケ", RelativeIndex: "1", Mode: getMode("SALMON")},
+			},
+		},
+		{
+			name: "the rule of the last clause must be shared with modes",
+			args: "オープンとXのヤグラ",
+			want: []*SearchQuery{
+				{OriginalText: "オープン", Mode: getMode("OPEN"), Rule: "LOFT"},
+				{OriginalText: "Xヤグラ", Mode: getMode("X"), Rule: "LOFT"},
+			},
+		},
+		{
+			name: "the question must apply to all clauses",
+			args: "ガチマ、リグマはいつまで？",
+			want: []*SearchQuery{
+				{OriginalText: "ガチマ", Mode: getMode("CHALLENGE"), Question: QuestionUntil},
+				{OriginalText: "リグマ", Mode: getMode("OPEN"), Question: QuestionUntil},
+			},
+		},
+		{
+			name:  "と in keywords must be proceed as a single query",
+			args:  "なわばりばとる",
+			loose: true,
+			want: []*SearchQuery{
+				{OriginalText: "ナワバリバトル", Mode: getMode("REGULAR"), Rule: "TURF_WAR"},
+			},
+		},
+		{
+			name: "clauses following other words must be proceed as a single query",
+			args: "ガチマとかシャケ",
+			want: []*SearchQuery{
+				{OriginalText: "シャケ", Mode: getMode("SALMON")},
+			},
+		},
+		{
+			name: "too many clauses must match nothing",
+			args: "ガチマとリグマとXとシャケ",
+			want: []*SearchQuery{
+				{Mode: getMode("")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseAll(tt.args)
+			if tt.loose {
+				got = ParseAllLoose(tt.args)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseAll_Normalized(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  string
+		loose bool
+		want  []string
+	}{
+		{name: "normalized clauses must be returned", args: "ガチマと次のしゃけはいつ？", loose: true, want: []string{"ガチマ", "次のシャケ"}},
+		{name: "strict clauses must keep hiragana", args: "ガチマと次のしゃけ", want: []string{"ガチマと次のしゃけ"}},
+		{name: "times must be normalized", args: "午後7時のXのアサリ", want: []string{"19時のXアサリ"}},
+		{name: "too many clauses must be returned", args: "エリアとヤグラとホコとアサリ", loose: true, want: []string{"エリア", "ヤグラ", "ホコ", "アサリ"}},
+		{name: "separators must not be folded", args: "エリアとこんにちは", loose: true, want: []string{"エリアとコンニチハ"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got, _ := parseAll(tt.args, tt.loose); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAll() normalized = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseAll_TooManyQueries(t *testing.T) {
+	if _, _, err := parseAll("エリアとヤグラとホコとアサリ", true); !errors.Is(err, errTooManyQueries) {
+		t.Errorf("parseAll() error = %v, want %v", err, errTooManyQueries)
+	}
+	if _, _, err := parseAll("エリアとヤグラとアサリ", true); err != nil {
+		t.Errorf("parseAll() error = %v, want nil", err)
+	}
+	// unrelated chats must not be rejected as too many queries
+	if _, _, err := parseAll("エリアとヤグラとホコとこんにちは", false); err != nil {
+		t.Errorf("parseAll() error = %v, want nil", err)
+	}
+}
+
+func TestParseAllEnglish(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want []*SearchQuery
+	}{
+		{
+			name: "clauses joined by and must be parsed respectively",
+			args: "When is next splat zones and salmon run?",
+			want: []*SearchQuery{
+				{OriginalText: "when is next splat zones", RelativeIndex: "1", Mode: getMode("BYRULE"), Rule: "AREA", Language: LocaleEN, Question: QuestionWhen},
+				{OriginalText: "salmon run", Mode: getMode("SALMON"), Language: LocaleEN, Question: QuestionWhen},
+			},
+		},
+		{
+			name: "any clause not understood must fail all",
+			args: "x match and the door",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAllEnglish(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAllEnglish() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
-	"net/url"
-	"os"
 	"time"
 )
 
@@ -19,6 +17,8 @@ type AllScheduleInfo struct {
 	BankaraChallenge []TimeSlotInfo `json:"bankara_challenge"`
 	BankaraOpen      []TimeSlotInfo `json:"bankara_open"`
 	XMatch           []TimeSlotInfo `json:"x"`
+	Event            []TimeSlotInfo `json:"event"`
+	Fest             []TimeSlotInfo `json:"fest"`
 }
 
 func (asi *AllScheduleInfo) getTimeSlotInfoByMode(mode Mode) []TimeSlotInfo {
@@ -31,6 +31,10 @@ func (asi *AllScheduleInfo) getTimeSlotInfoByMode(mode Mode) []TimeSlotInfo {
 		return asi.BankaraOpen
 	case getMode("X"):
 		return asi.XMatch
+	case getMode("EVENT"):
+		return asi.Event
+	case getMode("FEST"):
+		return asi.Fest
 	}
 	return []TimeSlotInfo{}
 }
@@ -41,6 +45,8 @@ type TimeSlotInfo struct {
 	Rule      RuleInfo    `json:"rule"`
 	Stages    []StageInfo `json:"stages"`
 	IsFest    bool        `json:"is_fest"`
+	// Event Match
+	Event *EventInfo `json:"event,omitempty"`
 	// Salmon Run
 	Stage    StageInfo    `json:"stage"`
 	Weapons  []WeaponInfo `json:"weapons"`
@@ -52,6 +58,12 @@ type RuleInfo struct {
 	Name string `json:"name"`
 }
 
+type EventInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+}
+
 type StageInfo struct {
 	ID    int64  `json:"id"`
 	Name  string `json:"name"`
@@ -67,24 +79,27 @@ type WeaponInfo struct {
 	Image string `json:"image"`
 }
 
+// upstreamClient gives up slow upstream APIs not to block searches holding the store lock
+var upstreamClient = &http.Client{Timeout: time.Second * 10}
+
 func query(url string) ([]byte, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("User-Agent", USER_AGENT)
-	client := http.Client{}
-	resp, err := client.Do(req)
+	req.Header.Add("User-Agent", getConfig().UserAgent)
+	resp, err := upstreamClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	return io.ReadAll(resp.Body)
 }
 
 func getSource() string {
-	return os.Getenv("IKABOT3_API_SOURCE")
+	return getConfig().Sources.Schedule
 }
 
 func fetchAll() (*AllAPIResult, error) {
@@ -103,15 +118,18 @@ func fetchAll() (*AllAPIResult, error) {
 }
 
 func FetchScheduleInfo() (*AllScheduleInfo, error) {
+	start := time.Now()
 	result, err := fetchAll()
+	metricsUpstreamFetchDuration.WithLabelValues("schedule").Observe(time.Since(start).Seconds())
 	if err != nil {
+		metricsUpstreamFetchErrorsTotal.WithLabelValues("schedule").Inc()
 		return nil, err
 	}
 	return &result.Result, nil
 }
 
 func fetchSalmon() (*SalmonAPIResult, error) {
-	url, err := url.JoinPath(getSource(), "..", "coop-grouping-regular/schedule")
+	url, err := getConfig().SalmonSource()
 	if err != nil {
 		return nil, err
 	}
@@ -130,8 +148,11 @@ func fetchSalmon() (*SalmonAPIResult, error) {
 }
 
 func FetchScheduleInfoSalmon() (*[]TimeSlotInfo, error) {
+	start := time.Now()
 	result, err := fetchSalmon()
+	metricsUpstreamFetchDuration.WithLabelValues("salmon").Observe(time.Since(start).Seconds())
 	if err != nil {
+		metricsUpstreamFetchErrorsTotal.WithLabelValues("salmon").Inc()
 		return nil, err
 	}
 	return &result.Results, nil
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ResponseCard is a transport-neutral rendering of a SearchResultSlot
+type ResponseCard struct {
+	ModeName string
+	Color    int
+	NotFound bool
+	// Title is a rule name, or a stage name for Salmon Run
+	Title     string
+	StartTime time.Time
+	EndTime   time.Time
+	// Lines are stage names, or weapon names for Salmon Run
+	Lines []string
+	// Locale is one of LocaleJA and LocaleEN; empty means LocaleJA
+	Locale string
+	// Key identifies the slot such as REGULAR-20221024T000000Z
+	Key string
+	// Label is an ASCII rule name drawn on banners
+	Label string
+	// StageImages are URLs of stage images in the order of stages
+	StageImages  []string
+	WeaponImages []string
+	// Now is the time of the search to show the remaining time; zero hides it
+	Now time.Time
+	// Question is one of questions given by keywords such as いつまで
+	Question string
+}
+
+const (
+	QuestionWhen      = "when"
+	QuestionUntil     = "until"
+	QuestionRemaining = "remaining"
+)
+
+func (rc ResponseCard) TimeRange() string {
+	return localize(rc.Locale, "time_range",
+		rc.StartTime.Month(), rc.StartTime.Day(), rc.StartTime.Hour(),
+		rc.EndTime.Month(), rc.EndTime.Day(), rc.EndTime.Hour())
+}
+
+// Remaining describes the time until the start or the end of the slot; empty for finished slots
+func (rc ResponseCard) Remaining() string {
+	if rc.Now.IsZero() {
+		return ""
+	}
+	if rc.Now.Before(rc.StartTime) {
+		return localize(rc.Locale, "starts_in", formatDuration(rc.StartTime.Sub(rc.Now), rc.Locale))
+	}
+	if rc.Now.Before(rc.EndTime) {
+		return localize(rc.Locale, "ends_in", formatDuration(rc.EndTime.Sub(rc.Now), rc.Locale))
+	}
+	return ""
+}
+
+// Answer replies to Question; formatTime and formatRelative allow transports to use their own markup
+func (rc ResponseCard) Answer(formatTime func(time.Time) string, formatRelative func(start bool) string) string {
+	if !rc.Now.Before(rc.EndTime) {
+		return localize(rc.Locale, "answer_finished")
+	}
+	started := !rc.Now.Before(rc.StartTime)
+	switch rc.Question {
+	case QuestionWhen:
+		if started {
+			return localize(rc.Locale, "answer_running", formatRelative(false))
+		}
+		return localize(rc.Locale, "answer_starts", formatTime(rc.StartTime), formatRelative(true))
+	case QuestionUntil:
+		return localize(rc.Locale, "answer_ends", formatTime(rc.EndTime), formatRelative(false))
+	case QuestionRemaining:
+		return formatRelative(!started)
+	}
+	return ""
+}
+
+func (rc ResponseCard) plainAnswer() string {
+	formatTime := func(t time.Time) string {
+		return localize(rc.Locale, "time_point", t.Month(), t.Day(), t.Hour())
+	}
+	formatRelative := func(start bool) string {
+		if start {
+			return localize(rc.Locale, "starts_in", formatDuration(rc.StartTime.Sub(rc.Now), rc.Locale))
+		}
+		return localize(rc.Locale, "ends_in", formatDuration(rc.EndTime.Sub(rc.Now), rc.Locale))
+	}
+	return rc.Answer(formatTime, formatRelative)
+}
+
+// Period is TimeRange followed by Remaining if any; questions are answered instead
+func (rc ResponseCard) Period() string {
+	if rc.Question != "" && !rc.Now.IsZero() {
+		return rc.plainAnswer()
+	}
+	if remaining := rc.Remaining(); remaining != "" {
+		return localize(rc.Locale, "period", rc.TimeRange(), remaining)
+	}
+	return rc.TimeRange()
+}
+
+func formatDuration(d time.Duration, locale string) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 60 {
+		return localize(locale, "duration_minutes", minutes)
+	}
+	return localize(locale, "duration_hours", minutes/60, minutes%60)
+}
+
+func (rc ResponseCard) Description() string {
+	if rc.NotFound {
+		return localize(rc.Locale, "not_found")
+	}
+	return fmt.Sprintf("%s\n\n%s", rc.Period(), strings.Join(rc.Lines, "\n"))
+}
+
+// PlainText renders the card in a few lines for text-only transports
+func (rc ResponseCard) PlainText() string {
+	if rc.NotFound {
+		return fmt.Sprintf("[%s] %s", rc.ModeName, localize(rc.Locale, "not_found"))
+	}
+	return fmt.Sprintf("[%s] %s %s\n%s", rc.ModeName, rc.Title, rc.Period(), strings.Join(rc.Lines, " / "))
+}
+
+func createResponseCard(srs SearchResultSlot) ResponseCard {
+	return createLocalizedResponseCard(srs, LocaleJA)
+}
+
+func createLocalizedResponseCard(srs SearchResultSlot, locale string) ResponseCard {
+	locale = normalizeLocale(locale)
+	if srs.tsi == nil {
+		return ResponseCard{
+			ModeName: srs.mode.getLocalizedModeName(locale),
+			NotFound: true,
+			Locale:   locale,
+		}
+	}
+	card := ResponseCard{
+		ModeName:  srs.mode.getLocalizedModeName(locale),
+		Color:     srs.mode.getColor(),
+		StartTime: srs.tsi.StartTime,
+		EndTime:   srs.tsi.EndTime,
+		Locale:    locale,
+		Key:       slotKey(slotModeKey(srs), srs.tsi),
+	}
+	if srs.mode.getIdentifier() == "SALMON" {
+		card.Title = localizeName(StageDictionary, srs.tsi.Stage.Name, locale)
+		card.Label = srs.mode.getLocalizedModeName(LocaleEN)
+		if srs.tsi.Stage.Image != "" {
+			card.StageImages = []string{srs.tsi.Stage.Image}
+		}
+		for _, weapon := range srs.tsi.Weapons {
+			card.Lines = append(card.Lines, localizeName(WeaponDictionary, weapon.Name, locale))
+			if weapon.Image != "" {
+				card.WeaponImages = append(card.WeaponImages, weapon.Image)
+			}
+		}
+	} else {
+		card.Title = localizeName(RuleDictionary, srs.tsi.Rule.Name, locale)
+		card.Label = localizeName(RuleDictionary, srs.tsi.Rule.Name, LocaleEN)
+		if card.Label == srs.tsi.Rule.Name {
+			// unknown rules are labeled by keys such as AREA
+			card.Label = srs.tsi.Rule.Key
+		}
+		if srs.tsi.Event != nil {
+			card.Title = localize(locale, "event_title", srs.tsi.Event.Name, card.Title)
+		}
+		for _, stage := range srs.tsi.Stages {
+			card.Lines = append(card.Lines, localizeName(StageDictionary, stage.Name, locale))
+			if stage.Image != "" {
+				card.StageImages = append(card.StageImages, stage.Image)
+			}
+		}
+	}
+	return card
+}
+
+func createResponseCards(sr SearchResult, locale string) []ResponseCard {
+	var cards []ResponseCard
+	for _, slot := range sr.Slots {
+		card := createLocalizedResponseCard(slot, locale)
+		card.Now = sr.TimeStamp
+		if sr.Query != nil {
+			card.Question = sr.Query.Question
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+// SlotView is a JSON representation of a SearchResultSlot for external consumers
+type SlotView struct {
+	Mode      string     `json:"mode"`
+	ModeName  string     `json:"mode_name"`
+	Found     bool       `json:"found"`
+	StartTime *time.Time `json:"start_time,omitempty"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+	Rule      *RuleInfo  `json:"rule,omitempty"`
+	Stages    []string   `json:"stages,omitempty"`
+	Weapons   []string   `json:"weapons,omitempty"`
+	IsBigRun  bool       `json:"is_big_run,omitempty"`
+	IsFest    bool       `json:"is_fest,omitempty"`
+	Event     *EventInfo `json:"event,omitempty"`
+}
+
+// slotModeKey distinguishes Big Run from Salmon Run though both share the identifier
+func slotModeKey(srs SearchResultSlot) string {
+	if srs.mode.getIdentifier() == "SALMON" && srs.tsi != nil && srs.tsi.IsBigRun {
+		return "BIGRUN"
+	}
+	return srs.mode.getIdentifier()
+}
+
+func createSlotView(srs SearchResultSlot) SlotView {
+	view := SlotView{
+		Mode:     slotModeKey(srs),
+		ModeName: srs.mode.getModeName(),
+		Found:    srs.tsi != nil,
+	}
+	if srs.tsi == nil {
+		return view
+	}
+	startTime, endTime := srs.tsi.StartTime, srs.tsi.EndTime
+	view.StartTime = &startTime
+	view.EndTime = &endTime
+	view.IsBigRun = srs.tsi.IsBigRun
+	view.IsFest = srs.tsi.IsFest
+	view.Event = srs.tsi.Event
+	if srs.mode.getIdentifier() == "SALMON" {
+		view.Stages = []string{srs.tsi.Stage.Name}
+		for _, weapon := range srs.tsi.Weapons {
+			view.Weapons = append(view.Weapons, weapon.Name)
+		}
+	} else {
+		rule := srs.tsi.Rule
+		view.Rule = &rule
+		for _, stage := range srs.tsi.Stages {
+			view.Stages = append(view.Stages, stage.Name)
+		}
+	}
+	return view
+}
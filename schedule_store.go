@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
@@ -14,6 +16,7 @@ type ScheduleStore struct {
 	salmonInfo  *[]TimeSlotInfo
 	cache       *FileCache
 	salmonCache *FileCache
+	events      *EventLog
 }
 
 type SearchResultSlot struct {
@@ -21,20 +24,30 @@ type SearchResultSlot struct {
 	tsi  *TimeSlotInfo
 }
 
-func NewScheduleStore() ScheduleStore {
+func NewScheduleStore(workdir string) ScheduleStore {
 	return ScheduleStore{
-		cache:       NewFileCache("./", "api_call_cache"),
-		salmonCache: NewFileCache("./", "api_call_cache_salmon"),
+		cache:       NewFileCache(workdir, "api_call_cache"),
+		salmonCache: NewFileCache(workdir, "api_call_cache_salmon"),
+		events:      NewEventLog(workdir, "schedule_events", 200),
 	}
 }
 
+// previousCacheBody returns the last cached data even if expired
+func previousCacheBody[T any](current *T, fc *FileCache) *T {
+	if current != nil {
+		return current
+	}
+	return MaybeGetFromFileCache[T](fc, time.Hour*24*365*100)
+}
+
 func (ss *ScheduleStore) maybeLoadInfo() {
 	ss.Lock()
 	defer ss.Unlock()
-	cached := MaybeGetFromFileCache[AllScheduleInfo](ss.cache, time.Minute*30)
+	cached := MaybeGetFromFileCache[AllScheduleInfo](ss.cache, getConfig().Cache.TTL)
 	if cached == nil {
 		// outdated. refresh schedule info
 		logger.Sugar().Infof("Cache %s is outdated. fetching...", ss.cache.CacheFileName)
+		previous := previousCacheBody(ss.info, ss.cache)
 		info, err := FetchScheduleInfo()
 		if err == nil {
 			logger.Sugar().Infof("Fetch %s completed", ss.cache.CacheFileName)
@@ -46,9 +59,14 @@ func (ss *ScheduleStore) maybeLoadInfo() {
 		if err != nil {
 			return
 		}
+		recordRefresh("schedule", ss.cache.UpdatedAt())
 		ss.info = info
+		if ss.events != nil {
+			ss.events.Append(diffScheduleInfo(previous, info, time.Now()))
+		}
 	} else {
 		logger.Sugar().Infof("Cache %s is valid", ss.cache.CacheFileName)
+		recordRefresh("schedule", ss.cache.UpdatedAt())
 		ss.info = cached
 	}
 }
@@ -56,10 +74,11 @@ func (ss *ScheduleStore) maybeLoadInfo() {
 func (ss *ScheduleStore) maybeLoadInfoSalmon() {
 	ss.Lock()
 	defer ss.Unlock()
-	cached := MaybeGetFromFileCache[[]TimeSlotInfo](ss.salmonCache, time.Minute*30)
+	cached := MaybeGetFromFileCache[[]TimeSlotInfo](ss.salmonCache, getConfig().Cache.SalmonTTL)
 	if cached == nil {
 		// outdated. refresh schedule info
 		logger.Sugar().Infof("Cache %s is outdated. fetching...", ss.salmonCache.CacheFileName)
+		previous := previousCacheBody(ss.salmonInfo, ss.salmonCache)
 		info, err := FetchScheduleInfoSalmon()
 		if err == nil {
 			logger.Sugar().Infof("Fetch %s completed", ss.salmonCache.CacheFileName)
@@ -70,22 +89,108 @@ func (ss *ScheduleStore) maybeLoadInfoSalmon() {
 		if err != nil {
 			return
 		}
+		recordRefresh("salmon", ss.salmonCache.UpdatedAt())
 		ss.salmonInfo = info
+		if ss.events != nil {
+			ss.events.Append(diffSalmonInfo(previous, info, time.Now()))
+		}
 	} else {
 		logger.Sugar().Infof("Cache %s is valid", ss.salmonCache.CacheFileName)
+		recordRefresh("salmon", ss.salmonCache.UpdatedAt())
 		ss.salmonInfo = cached
 	}
 }
 
+// CheckFreshness returns an error if schedules are missing or older than maxAge
+func (ss *ScheduleStore) CheckFreshness(maxAge time.Duration) error {
+	ss.RLock()
+	defer ss.RUnlock()
+	if ss.info == nil || ss.salmonInfo == nil {
+		return errors.New("schedule data is not loaded")
+	}
+	for _, fc := range []*FileCache{ss.cache, ss.salmonCache} {
+		if updated := fc.UpdatedAt(); time.Since(updated) > maxAge {
+			return fmt.Errorf("%s is outdated; updated at %s", fc.CacheFileName, updated.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
 func (ss *ScheduleStore) MaybeRefresh() {
 	ss.maybeLoadInfo()
 	ss.maybeLoadInfoSalmon()
 }
 
+// RefreshEvery keeps caches fresh while nobody asks the bot until stop is closed
+func (ss *ScheduleStore) RefreshEvery(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ss.MaybeRefresh()
+		}
+	}
+}
+
+// Snapshot returns the cached schedules; callers must not modify them
+func (ss *ScheduleStore) Snapshot() (*AllScheduleInfo, *[]TimeSlotInfo) {
+	ss.RLock()
+	defer ss.RUnlock()
+	return ss.info, ss.salmonInfo
+}
+
+// Events returns schedule change events in the newest first order
+func (ss *ScheduleStore) Events() []ScheduleEvent {
+	if ss.events == nil {
+		return nil
+	}
+	return ss.events.Events()
+}
+
+// Names returns stage and weapon names appeared in the cached schedules
+func (ss *ScheduleStore) StageNames() []string {
+	var stages []string
+	ss.RLock()
+	defer ss.RUnlock()
+	if ss.info != nil {
+		for _, mode := range []string{"REGULAR", "CHALLENGE", "OPEN", "X"} {
+			for _, tsinfo := range ss.info.getTimeSlotInfoByMode(getMode(mode)) {
+				for _, stage := range tsinfo.Stages {
+					stages = append(stages, stage.Name)
+				}
+			}
+		}
+	}
+	if ss.salmonInfo != nil {
+		for _, tsinfo := range *ss.salmonInfo {
+			stages = append(stages, tsinfo.Stage.Name)
+		}
+	}
+	return stages
+}
+
 type SearchResult struct {
 	Query *SearchQuery
 	Found bool
 	Slots []SearchResultSlot
+	// TimeStamp is the time when the search is performed
+	TimeStamp time.Time
+	// Trace tells how slots are looked up for explanations
+	Trace SearchTrace
+}
+
+// SearchTrace records the lookup chosen by the search and its filters
+type SearchTrace struct {
+	// Lookup is one of overview, salmon, rule and time
+	Lookup string
+	// Source is the upstream data consulted; schedule or salmon
+	Source string
+	Modes  []string
+	// Filters are conditions such as rule=AREA in the order applied
+	Filters []string
 }
 
 func lookupByAbsoluteTime(asi *AllScheduleInfo, mode Mode, hour int) (matched SearchResultSlot, found bool) {
@@ -115,16 +220,89 @@ func lookupByRule(asi *AllScheduleInfo, mode Mode, ruleKey string, skipCount int
 	return SearchResultSlot{mode, nil}, false
 }
 
+// lookupByTime returns the slot running at the time; Splatfest slots are found only in FEST
+func lookupByTime(asi *AllScheduleInfo, mode Mode, t time.Time) (matched SearchResultSlot, found bool) {
+	for _, tsinfo := range asi.getTimeSlotInfoByMode(mode) {
+		if tsinfo.IsFest && mode != getMode("FEST") {
+			continue
+		}
+		if !t.Before(tsinfo.StartTime) && t.Before(tsinfo.EndTime) {
+			return SearchResultSlot{mode, &tsinfo}, true
+		}
+	}
+	return SearchResultSlot{mode, nil}, false
+}
+
+// overviewModes are listed by the overview in this order; modes without slots such as FEST are omitted
+var overviewModes = []string{"REGULAR", "OPEN", "CHALLENGE", "X", "FEST", "EVENT"}
+
+// searchOverview lists slots of all modes; RelativeIndex moves both battles and Salmon Run by their rotations
+func searchOverview(query *SearchQuery, info *AllScheduleInfo, salmonInfo *[]TimeSlotInfo, timeStamp time.Time) SearchResult {
+	relativeIdx, err := strconv.Atoi(query.RelativeIndex)
+	if err != nil {
+		relativeIdx = 0
+	}
+	t := timeStamp.Add(time.Hour * 2 * time.Duration(relativeIdx))
+	var slots []SearchResultSlot
+	for _, identifier := range overviewModes {
+		if matched, found := lookupByTime(info, getMode(identifier), t); found {
+			slots = append(slots, matched)
+		}
+	}
+	salmon := searchSalmon(&SearchQuery{RelativeIndex: query.RelativeIndex}, salmonInfo, timeStamp)
+	if salmon.Found {
+		slots = append(slots, salmon.Slots...)
+	}
+	return SearchResult{
+		Query: query,
+		Found: len(slots) > 0,
+		Slots: slots,
+		Trace: SearchTrace{
+			Lookup:  "overview",
+			Source:  "schedule, salmon",
+			Modes:   append(append([]string{}, overviewModes...), "SALMON"),
+			Filters: []string{"running_at=" + t.Format(time.RFC3339), fmt.Sprintf("salmon_index=%d", relativeIdx)},
+		},
+	}
+}
+
 func (ss *ScheduleStore) Search(query *SearchQuery) SearchResult {
 	ss.RLock()
 	defer ss.RUnlock()
-	if query.Mode.getIdentifier() == "SALMON" {
-		return searchSalmon(query, ss.salmonInfo, time.Now())
+	return searchAll(query, ss.info, ss.salmonInfo, ss.Now())
+}
+
+func (ss *ScheduleStore) Now() time.Time {
+	return time.Now()
+}
+
+// DataSource describes an upstream API and when its cache is updated
+type DataSource struct {
+	Name      string
+	URL       string
+	UpdatedAt time.Time
+}
+
+func (ss *ScheduleStore) Sources() []DataSource {
+	salmonURL, _ := getConfig().SalmonSource()
+	return []DataSource{
+		{Name: "schedule", URL: getSource(), UpdatedAt: ss.cache.UpdatedAt()},
+		{Name: "salmon", URL: salmonURL, UpdatedAt: ss.salmonCache.UpdatedAt()},
+	}
+}
+
+func searchAll(query *SearchQuery, info *AllScheduleInfo, salmonInfo *[]TimeSlotInfo, timeStamp time.Time) SearchResult {
+	var sr SearchResult
+	if query.Mode.getIdentifier() == "ALL" {
+		sr = searchOverview(query, info, salmonInfo, timeStamp)
+	} else if query.Mode.getIdentifier() == "SALMON" {
+		sr = searchSalmon(query, salmonInfo, timeStamp)
 	} else {
-		sr := search(query, ss.info, time.Now())
+		sr = search(query, info, timeStamp)
 		logger.Debug("search result", zap.Any("result", sr))
-		return sr
 	}
+	sr.TimeStamp = timeStamp
+	return sr
 }
 
 func searchSalmon(query *SearchQuery, salmonInfo *[]TimeSlotInfo, timeStamp time.Time) SearchResult {
@@ -132,9 +310,9 @@ func searchSalmon(query *SearchQuery, salmonInfo *[]TimeSlotInfo, timeStamp time
 	if err != nil {
 		relativeIdx = 0
 	}
-	found := relativeIdx < len(*salmonInfo)
+	found := relativeIdx >= 0 && relativeIdx < len(*salmonInfo)
 	var result *TimeSlotInfo
-	var mode Mode
+	mode := getMode("SALMON")
 	if found {
 		result = &(*salmonInfo)[relativeIdx]
 		if result.IsBigRun {
@@ -151,6 +329,12 @@ func searchSalmon(query *SearchQuery, salmonInfo *[]TimeSlotInfo, timeStamp time
 		Slots: []SearchResultSlot{
 			{mode, result},
 		},
+		Trace: SearchTrace{
+			Lookup:  "salmon",
+			Source:  "salmon",
+			Modes:   []string{"SALMON"},
+			Filters: []string{fmt.Sprintf("index=%d of %d slots", relativeIdx, len(*salmonInfo))},
+		},
 	}
 }
 
@@ -167,6 +351,7 @@ func search(query *SearchQuery, info *AllScheduleInfo, timeStamp time.Time) Sear
 		} else {
 			skipCount = 0
 		}
+		trace := SearchTrace{Lookup: "rule", Source: "schedule", Filters: []string{"rule=" + query.Rule, fmt.Sprintf("skip=%d", skipCount)}}
 
 		// XXX: special case using pseudo mode
 		if query.Mode.getIdentifier() == "BYRULE" {
@@ -174,36 +359,44 @@ func search(query *SearchQuery, info *AllScheduleInfo, timeStamp time.Time) Sear
 			matched2, found2 := lookupByRule(info, getMode("OPEN"), query.Rule, skipCount)
 			matched3, found3 := lookupByRule(info, getMode("X"), query.Rule, skipCount)
 			logger.Debug("search result", zap.Any("matched1", matched1), zap.Any("matched2", matched2), zap.Any("matched3", matched3))
+			trace.Modes = []string{"CHALLENGE", "OPEN", "X"}
 			return SearchResult{
 				Query: query,
 				Found: found1 || found2 || found3,
 				Slots: []SearchResultSlot{matched1, matched2, matched3},
+				Trace: trace,
 			}
 		} else if query.Mode.getIdentifier() == "BANKARA" {
 			matched1, found1 := lookupByRule(info, getMode("CHALLENGE"), query.Rule, skipCount)
 			matched2, found2 := lookupByRule(info, getMode("OPEN"), query.Rule, skipCount)
 			logger.Debug("search result", zap.Any("matched1", matched1), zap.Any("matched2", matched2))
+			trace.Modes = []string{"CHALLENGE", "OPEN"}
 			return SearchResult{
 				Query: query,
 				Found: found1 || found2,
 				Slots: []SearchResultSlot{matched1, matched2},
+				Trace: trace,
 			}
 		} else if query.Rule == "TURF_WAR" {
 			// TODO: support Splatfest schedule search
 			matched, found := lookupByRule(info, getMode("REGULAR"), query.Rule, skipCount)
 			logger.Debug("search result", zap.Any("matched", matched))
+			trace.Modes = []string{"REGULAR"}
 			return SearchResult{
 				Query: query,
 				Found: found,
 				Slots: []SearchResultSlot{matched},
+				Trace: trace,
 			}
 		} else {
 			matched, found := lookupByRule(info, query.Mode, query.Rule, skipCount)
 			logger.Debug("search result", zap.Any("matched", matched))
+			trace.Modes = []string{query.Mode.getIdentifier()}
 			return SearchResult{
 				Query: query,
 				Found: found,
 				Slots: []SearchResultSlot{matched},
+				Trace: trace,
 			}
 		}
 	}
@@ -225,29 +418,42 @@ func search(query *SearchQuery, info *AllScheduleInfo, timeStamp time.Time) Sear
 	if query.TimeIndex != "" {
 		timeIdx, err := strconv.Atoi(query.TimeIndex)
 		if err == nil {
-			absoluteStartTime = (timeIdx - ((timeIdx + 1) % 2)) % 24
+			// wrap 0 時 into the slot from 23 時 and 25 時 into the slot from 1 時
+			absoluteStartTime = ((timeIdx-((timeIdx+1)%2))%24 + 24) % 24
 		}
 	}
 	logger.Sugar().Debugf("absolute start time: %d", absoluteStartTime)
+	trace := SearchTrace{Lookup: "time", Source: "schedule"}
+	if query.RelativeIndex != "" {
+		trace.Filters = append(trace.Filters, "relative="+query.RelativeIndex)
+	}
+	if query.TimeIndex != "" {
+		trace.Filters = append(trace.Filters, "time="+query.TimeIndex)
+	}
+	trace.Filters = append(trace.Filters, fmt.Sprintf("window=%02d:00-%02d:00 JST", absoluteStartTime, (absoluteStartTime+2)%24))
 
 	if query.Mode.getIdentifier() == "BANKARA" {
 		// search case #2: lookup both by time
 		matched1, found1 := lookupByAbsoluteTime(info, getMode("CHALLENGE"), absoluteStartTime)
 		matched2, found2 := lookupByAbsoluteTime(info, getMode("OPEN"), absoluteStartTime)
 		logger.Debug("search result", zap.Any("matched1", matched1), zap.Any("matched2", matched2))
+		trace.Modes = []string{"CHALLENGE", "OPEN"}
 		return SearchResult{
 			Query: query,
 			Found: found1 || found2,
 			Slots: []SearchResultSlot{matched1, matched2},
+			Trace: trace,
 		}
 	} else {
 		// search case #3: lookup single by time
 		matched, found := lookupByAbsoluteTime(info, query.Mode, absoluteStartTime)
 		logger.Debug("search result", zap.Any("matched", matched))
+		trace.Modes = []string{query.Mode.getIdentifier()}
 		return SearchResult{
 			Query: query,
 			Found: found,
 			Slots: []SearchResultSlot{matched},
+			Trace: trace,
 		}
 	}
 }
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const SLACK_API_BASE_URL = "https://slack.com/api/"
+
+type SlackBot struct {
+	Core          *Bot
+	SigningSecret string
+	BotToken      string
+	// APIBaseURL is replaced by a fake server in tests
+	APIBaseURL string
+	client     *http.Client
+	now        func() time.Time
+}
+
+type SlackBotConfig struct {
+	SigningSecret string
+	BotToken      string
+	APIBaseURL    string
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color,omitempty"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackMessage struct {
+	Channel      string            `json:"channel,omitempty"`
+	ThreadTS     string            `json:"thread_ts,omitempty"`
+	ResponseType string            `json:"response_type,omitempty"`
+	Text         string            `json:"text"`
+	Attachments  []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackEvent struct {
+	Type    string `json:"type"`
+	User    string `json:"user"`
+	BotID   string `json:"bot_id"`
+	Text    string `json:"text"`
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+type slackEventCallback struct {
+	Type      string     `json:"type"`
+	Challenge string     `json:"challenge"`
+	Event     slackEvent `json:"event"`
+}
+
+type slackAPIResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func NewSlackBot(core *Bot, config SlackBotConfig) *SlackBot {
+	apiBaseURL := config.APIBaseURL
+	if apiBaseURL == "" {
+		apiBaseURL = SLACK_API_BASE_URL
+	}
+	return &SlackBot{
+		Core:          core,
+		SigningSecret: config.SigningSecret,
+		BotToken:      config.BotToken,
+		APIBaseURL:    apiBaseURL,
+		client:        &http.Client{Timeout: time.Second * 10},
+		now:           time.Now,
+	}
+}
+
+func (sb *SlackBot) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slack/events", sb.handleEvents)
+	mux.HandleFunc("/slack/commands", sb.handleCommands)
+	return mux
+}
+
+func signSlackRequest(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyRequest checks the signature as described in https://api.slack.com/authentication/verifying-requests-from-slack
+func (sb *SlackBot) verifyRequest(r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, false
+	}
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	// reject replayed requests
+	if sb.now().Sub(time.Unix(ts, 0)).Abs() > time.Minute*5 {
+		return nil, false
+	}
+	expected := signSlackRequest(sb.SigningSecret, timestamp, body)
+	return body, hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Slack-Signature")))
+}
+
+func removeSlackMention(input string) string {
+	regex := regexp.MustCompile(` *<@[A-Z0-9]+(\|[^>]*)?> *`)
+	return regex.ReplaceAllString(input, "")
+}
+
+func colorToHex(color int) string {
+	return fmt.Sprintf("#%06x", color)
+}
+
+func createSlackMessage(resp BotResponse) slackMessage {
+	if len(resp.Cards) == 0 {
+		return slackMessage{Text: resp.Text}
+	}
+	var texts []string
+	var attachments []slackAttachment
+	for _, card := range resp.Cards {
+		texts = append(texts, card.PlainText())
+		var text string
+		if card.NotFound {
+			text = fmt.Sprintf("*%s*\n%s", card.ModeName, card.Description())
+		} else {
+			text = fmt.Sprintf("*%s*\n*%s*  %s\n%s", card.ModeName, card.Title, card.Period(), strings.Join(card.Lines, "\n"))
+		}
+		attachments = append(attachments, slackAttachment{
+			Color: colorToHex(card.Color),
+			Blocks: []slackBlock{
+				{
+					Type: "section",
+					Text: &slackText{Type: "mrkdwn", Text: text},
+				},
+			},
+		})
+	}
+	return slackMessage{
+		// fallback for notifications
+		Text:        strings.Join(texts, "\n"),
+		Attachments: attachments,
+	}
+}
+
+func (sb *SlackBot) postMessage(msg slackMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", sb.APIBaseURL+"chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+sb.BotToken)
+	resp, err := sb.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var result slackAPIResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return err
+	}
+	if !result.OK {
+		return fmt.Errorf("chat.postMessage failed: %s", result.Error)
+	}
+	return nil
+}
+
+func (sb *SlackBot) handleMention(event slackEvent) {
+	resp := sb.Core.Handle(BotRequest{
+		Text:      removeSlackMention(event.Text),
+		Mentioned: true,
+		Transport: "slack",
+	})
+	if resp.Ignored {
+		return
+	}
+	msg := createSlackMessage(resp)
+	msg.Channel = event.Channel
+	msg.ThreadTS = event.TS
+	err := sb.postMessage(msg)
+	if err != nil {
+		logger.Sugar().Error(err)
+	}
+}
+
+func (sb *SlackBot) handleEvents(w http.ResponseWriter, r *http.Request) {
+	body, ok := sb.verifyRequest(r)
+	if !ok {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	// Slack retries when we respond slowly; the first request is still being processed
+	if r.Header.Get("X-Slack-Retry-Num") != "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	var callback slackEventCallback
+	err := json.Unmarshal(body, &callback)
+	if err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	switch callback.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(callback.Challenge))
+		return
+	case "event_callback":
+		if callback.Event.Type == "app_mention" && callback.Event.BotID == "" {
+			// Slack expects a response within 3 seconds
+			go sb.handleMention(callback.Event)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (sb *SlackBot) handleCommands(w http.ResponseWriter, r *http.Request) {
+	body, ok := sb.verifyRequest(r)
+	if !ok {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	resp := sb.Core.Handle(BotRequest{
+		Command:   "ika",
+		Options:   map[string]string{"query": r.PostForm.Get("text")},
+		Transport: "slack",
+	})
+	msg := createSlackMessage(resp)
+	msg.ResponseType = "in_channel"
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(msg)
+	if err != nil {
+		logger.Sugar().Error(err)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSlackSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func newTestSlackBot(apiBaseURL string) *SlackBot {
+	sb := NewSlackBot(NewBot(newTestStore()), SlackBotConfig{
+		SigningSecret: testSlackSecret,
+		BotToken:      "xoxb-test",
+		APIBaseURL:    apiBaseURL,
+	})
+	sb.now = func() time.Time { return testNow }
+	return sb
+}
+
+func newSignedSlackRequest(path string, body string, contentType string) *http.Request {
+	timestamp := strconv.FormatInt(testNow.Unix(), 10)
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", signSlackRequest(testSlackSecret, timestamp, []byte(body)))
+	return req
+}
+
+func TestSlackBot_URLVerification(t *testing.T) {
+	sb := newTestSlackBot("")
+	rec := httptest.NewRecorder()
+	req := newSignedSlackRequest("/slack/events", `{"type":"url_verification","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`, "application/json")
+	sb.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P" {
+		t.Errorf("url_verification = %d %v", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSlackBot_InvalidSignature(t *testing.T) {
+	sb := newTestSlackBot("")
+	rec := httptest.NewRecorder()
+	req := newSignedSlackRequest("/slack/events", `{"type":"url_verification","challenge":"x"}`, "application/json")
+	req.Header.Set("X-Slack-Signature", "v0=invalid")
+	sb.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("invalid signature = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestSlackBot_AppMention(t *testing.T) {
+	posted := make(chan slackMessage, 1)
+	fakeSlack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat.postMessage" || r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("unexpected request: %v %v", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var msg slackMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		posted <- msg
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer fakeSlack.Close()
+
+	sb := newTestSlackBot(fakeSlack.URL + "/api/")
+	rec := httptest.NewRecorder()
+	req := newSignedSlackRequest("/slack/events", `{"type":"event_callback","event":{"type":"app_mention","user":"U061F7AUR","text":"<@U0LAN0Z89> 次のガチマ","ts":"1515449522.000016","channel":"C0LAN2Q65"}}`, "application/json")
+	sb.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("app_mention = %d", rec.Code)
+	}
+
+	select {
+	case msg := <-posted:
+		if msg.Channel != "C0LAN2Q65" || msg.ThreadTS != "1515449522.000016" {
+			t.Errorf("posted to %v %v", msg.Channel, msg.ThreadTS)
+		}
+		if len(msg.Attachments) != 1 || msg.Attachments[0].Color != "#f64a10" {
+			t.Fatalf("attachments = %#v", msg.Attachments)
+		}
+		text := msg.Attachments[0].Blocks[0].Text.Text
+		if !strings.Contains(text, "ガチヤグラ") || !strings.Contains(text, "ナメロウ金属") || !strings.Contains(text, "クサヤ温泉") {
+			t.Errorf("section text = %v", text)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("chat.postMessage was not called")
+	}
+}
+
+func TestSlackBot_SlashCommand(t *testing.T) {
+	sb := newTestSlackBot("")
+	form := url.Values{}
+	form.Set("command", "/ika")
+	form.Set("text", "シャケ")
+	rec := httptest.NewRecorder()
+	req := newSignedSlackRequest("/slack/commands", form.Encode(), "application/x-www-form-urlencoded")
+	sb.Handler().ServeHTTP(rec, req)
+
+	var msg slackMessage
+	err := json.Unmarshal(rec.Body.Bytes(), &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.ResponseType != "in_channel" || len(msg.Attachments) != 1 || msg.Attachments[0].Color != "#ff501e" {
+		t.Fatalf("response = %#v", msg)
+	}
+	if text := msg.Attachments[0].Blocks[0].Text.Text; !strings.Contains(text, "シェケナダム") {
+		t.Errorf("section text = %v", text)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// ScheduleTable lists all known slots of modes which are not finished yet
+type ScheduleTable struct {
+	Modes  []Mode
+	Rows   []ScheduleTableRow
+	Locale string
+}
+
+type ScheduleTableRow struct {
+	StartTime time.Time
+	// Cards are in the order of Modes; NotFound if no slot of the mode starts at the time
+	Cards []ResponseCard
+}
+
+// tableModes maps choices of /table to modes shown as columns
+var tableModes = map[string][]string{
+	"ALL":       {"REGULAR", "OPEN", "CHALLENGE", "X"},
+	"BANKARA":   {"CHALLENGE", "OPEN"},
+	"REGULAR":   {"REGULAR"},
+	"OPEN":      {"OPEN"},
+	"CHALLENGE": {"CHALLENGE"},
+	"X":         {"X"},
+	"SALMON":    {"SALMON"},
+}
+
+func buildScheduleTable(info *AllScheduleInfo, salmonInfo *[]TimeSlotInfo, identifiers []string, now time.Time, locale string) ScheduleTable {
+	table := ScheduleTable{Locale: normalizeLocale(locale)}
+	slots := map[int64][]*TimeSlotInfo{}
+	for column, identifier := range identifiers {
+		table.Modes = append(table.Modes, getMode(identifier))
+		var tsinfos []TimeSlotInfo
+		if identifier == "SALMON" {
+			if salmonInfo != nil {
+				tsinfos = *salmonInfo
+			}
+		} else if info != nil {
+			tsinfos = info.getTimeSlotInfoByMode(getMode(identifier))
+		}
+		for idx := range tsinfos {
+			tsinfo := &tsinfos[idx]
+			if !tsinfo.EndTime.After(now) || tsinfo.IsFest {
+				continue
+			}
+			key := tsinfo.StartTime.Unix()
+			if slots[key] == nil {
+				slots[key] = make([]*TimeSlotInfo, len(identifiers))
+			}
+			slots[key][column] = tsinfo
+		}
+	}
+
+	var keys []int64
+	for key := range slots {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		var row ScheduleTableRow
+		for column, tsinfo := range slots[key] {
+			mode := table.Modes[column]
+			if tsinfo != nil {
+				row.StartTime = tsinfo.StartTime
+				if tsinfo.IsBigRun {
+					mode = getMode("BIGRUN")
+				}
+			}
+			card := createLocalizedResponseCard(SearchResultSlot{mode, tsinfo}, table.Locale)
+			card.Now = now
+			row.Cards = append(row.Cards, card)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// displayWidth counts East Asian wide characters as 2 columns
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			w += 2
+		default:
+			w += 1
+		}
+	}
+	return w
+}
+
+func padRight(s string, w int) string {
+	return s + strings.Repeat(" ", w-displayWidth(s))
+}
+
+// Text renders the table in monospaced lines up to maxLength characters; later rows are omitted
+func (st ScheduleTable) Text(maxLength int) string {
+	modeWidth, titleWidth := 0, 0
+	for _, row := range st.Rows {
+		for column, card := range row.Cards {
+			if card.NotFound {
+				continue
+			}
+			if w := displayWidth(st.modeLabel(column, card)); w > modeWidth {
+				modeWidth = w
+			}
+			if w := displayWidth(card.Title); w > titleWidth {
+				titleWidth = w
+			}
+		}
+	}
+
+	var text string
+	for idx, row := range st.Rows {
+		lines := []string{localize(st.Locale, "time_point", row.StartTime.Month(), row.StartTime.Day(), row.StartTime.Hour())}
+		for column, card := range row.Cards {
+			if card.NotFound {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  %s  %s  %s",
+				padRight(st.modeLabel(column, card), modeWidth), padRight(card.Title, titleWidth), strings.Join(card.Lines, " / ")))
+		}
+		block := strings.Join(lines, "\n")
+		if idx > 0 {
+			block = "\n" + block
+		}
+		// keep a room for the ellipsis
+		if utf8.RuneCountInString(text)+utf8.RuneCountInString(block)+2 > maxLength {
+			text += "\n…"
+			break
+		}
+		text += block
+	}
+	return text
+}
+
+func (st ScheduleTable) modeLabel(column int, card ResponseCard) string {
+	identifier := st.Modes[column].getIdentifier()
+	if strings.HasPrefix(card.Key, "BIGRUN-") {
+		identifier = "BIGRUN"
+	}
+	return localize(st.Locale, "mode_short_"+identifier)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_displayWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want int
+	}{
+		{name: "ASCII must be 1 column", args: "X", want: 1},
+		{name: "katakana must be 2 columns", args: "オープン", want: 8},
+		{name: "fullwidth symbols must be 2 columns", args: "リゾート＆スパ", want: 14},
+		{name: "mixed text must be summed", args: "X マッチ", want: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := displayWidth(tt.args); got != tt.want {
+				t.Errorf("displayWidth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_buildScheduleTable(t *testing.T) {
+	store := newTestStore()
+	table := buildScheduleTable(store.info, store.salmonInfo, tableModes["BANKARA"], testNow, LocaleJA)
+	// finished slots must be skipped; slots of 11 時 to 21 時 are left
+	if len(table.Rows) != 5 {
+		t.Fatalf("len(Rows) = %d, want 5", len(table.Rows))
+	}
+	first := table.Rows[0]
+	if !first.StartTime.Equal(time.Date(2023, 3, 2, 11, 0, 0, 0, testJST)) {
+		t.Errorf("Rows[0].StartTime = %v", first.StartTime)
+	}
+	if first.Cards[0].Title != "ガチエリア" || first.Cards[1].Title != "ガチヤグラ" {
+		t.Errorf("Rows[0] = %v, %v; want Challenge and Open in order", first.Cards[0].Title, first.Cards[1].Title)
+	}
+	// Open has one slot less than Challenge in the test store
+	last := table.Rows[len(table.Rows)-1]
+	if last.Cards[0].NotFound || !last.Cards[1].NotFound {
+		t.Errorf("last row must have Challenge only: %v", last.Cards)
+	}
+
+	salmon := buildScheduleTable(store.info, store.salmonInfo, tableModes["SALMON"], testNow, LocaleJA)
+	if len(salmon.Rows) != 3 || salmon.modeLabel(0, salmon.Rows[2].Cards[0]) != "ビッグラン" {
+		t.Errorf("Salmon Run table must have 3 rows ending with Big Run: %v", salmon.Rows)
+	}
+}
+
+func TestScheduleTable_Text(t *testing.T) {
+	store := newTestStore()
+	table := buildScheduleTable(store.info, store.salmonInfo, tableModes["BANKARA"], testNow, LocaleJA)
+	text := table.Text(tableTextLength)
+	want := "3/2 11時\n" +
+		"  チャレンジ  ガチエリア      ヤガラ市場 / マテガイ放水路\n" +
+		"  オープン    ガチヤグラ      ナメロウ金属 / クサヤ温泉\n"
+	if !strings.HasPrefix(text, want) {
+		t.Errorf("Text() = %v, want prefix %v", text, want)
+	}
+
+	short := table.Text(100)
+	if len([]rune(short)) > 100 || !strings.HasSuffix(short, "\n…") {
+		t.Errorf("Text() must be truncated with an ellipsis: %v", short)
+	}
+}
+
+func Test_composeTableImage(t *testing.T) {
+	store := newTestStore()
+	table := buildScheduleTable(store.info, store.salmonInfo, tableModes["ALL"], testNow, LocaleJA)
+	grid := composeTableImage(table, map[string]image.Image{})
+	want := image.Pt(tableTimeWidth+tableCellWidth*4, bannerLabelHeight+(bannerLabelHeight+tableCellHeight)*len(table.Rows))
+	if grid.Bounds().Size() != want {
+		t.Errorf("size = %v, want %v", grid.Bounds().Size(), want)
+	}
+}
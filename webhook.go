@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type WebhookTarget struct {
+	URL string `json:"url"`
+	// Format is either "discord" (embeds) or "json" (SlotView)
+	Format string `json:"format"`
+	// Modes and Rules filter slots to publish; empty means all
+	Modes []string `json:"modes"`
+	Rules []string `json:"rules"`
+}
+
+type WebhookConfig struct {
+	Targets []WebhookTarget `json:"targets"`
+}
+
+type WebhookPublisher struct {
+	Store      Searcher
+	Targets    []WebhookTarget
+	MaxRetries int
+	// RetryWait is doubled on each retry
+	RetryWait time.Duration
+	client    *http.Client
+	// signatures of the last published slot by mode
+	published map[string]string
+	stop      chan struct{}
+}
+
+type discordWebhookPayload struct {
+	Username string                    `json:"username,omitempty"`
+	Embeds   []*discordgo.MessageEmbed `json:"embeds"`
+}
+
+type jsonWebhookPayload struct {
+	Event string   `json:"event"`
+	Slot  SlotView `json:"slot"`
+}
+
+var webhookModes = []string{"REGULAR", "OPEN", "CHALLENGE", "X", "SALMON"}
+
+func LoadWebhookConfig(filename string) (*WebhookConfig, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config WebhookConfig
+	err = json.Unmarshal(bytes, &config)
+	if err != nil {
+		return nil, err
+	}
+	for idx, target := range config.Targets {
+		if target.URL == "" {
+			return nil, fmt.Errorf("targets[%d]: url is empty", idx)
+		}
+		if target.Format != "discord" && target.Format != "json" {
+			return nil, fmt.Errorf("targets[%d]: unknown format '%s'", idx, target.Format)
+		}
+	}
+	return &config, nil
+}
+
+func NewWebhookPublisher(store Searcher, targets []WebhookTarget) *WebhookPublisher {
+	return &WebhookPublisher{
+		Store:      store,
+		Targets:    targets,
+		MaxRetries: 3,
+		RetryWait:  time.Second,
+		client:     &http.Client{Timeout: time.Second * 10},
+		stop:       make(chan struct{}),
+	}
+}
+
+func containsOrEmpty(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (target WebhookTarget) accepts(srs SearchResultSlot) bool {
+	modeKey := slotModeKey(srs)
+	// SALMON includes Big Run
+	if !containsOrEmpty(target.Modes, modeKey) && !(modeKey == "BIGRUN" && containsOrEmpty(target.Modes, "SALMON")) {
+		return false
+	}
+	if srs.mode.getIdentifier() == "SALMON" {
+		// Salmon Run has no rules
+		return len(target.Rules) == 0
+	}
+	return containsOrEmpty(target.Rules, srs.tsi.Rule.Key)
+}
+
+func (target WebhookTarget) createPayload(srs SearchResultSlot) interface{} {
+	if target.Format == "discord" {
+		return discordWebhookPayload{
+			Username: "ikabot3",
+			Embeds:   []*discordgo.MessageEmbed{createMessageEmbedFromResponseCard(createResponseCard(srs))},
+		}
+	}
+	return jsonWebhookPayload{
+		Event: "rotation",
+		Slot:  createSlotView(srs),
+	}
+}
+
+func slotSignature(srs SearchResultSlot) string {
+	bytes, _ := json.Marshal(createSlotView(srs))
+	return string(bytes)
+}
+
+func (wp *WebhookPublisher) post(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	wait := wp.RetryWait
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", getConfig().UserAgent)
+		resp, err := wp.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("webhook responded %s", resp.Status)
+			// do not retry on client errors except rate limits
+			if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+				return err
+			}
+			if sec, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil {
+				wait = time.Second * time.Duration(sec)
+			}
+		}
+		if attempt >= wp.MaxRetries {
+			return err
+		}
+		logger.Sugar().Warnf("Webhook failed; retrying in %v: %v", wait, err)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+func (wp *WebhookPublisher) publish(srs SearchResultSlot) {
+	for _, target := range wp.Targets {
+		if !target.accepts(srs) {
+			continue
+		}
+		err := wp.post(target.URL, target.createPayload(srs))
+		if err != nil {
+			logger.Sugar().Errorf("Cannot publish to webhook: %v", err)
+		}
+	}
+}
+
+// Check publishes current slots changed since the last check; the first check only records them
+func (wp *WebhookPublisher) Check() {
+	wp.Store.MaybeRefresh()
+	initial := wp.published == nil
+	if initial {
+		wp.published = map[string]string{}
+	}
+	for _, mode := range webhookModes {
+		sr := wp.Store.Search(&SearchQuery{Mode: getMode(mode)})
+		if !sr.Found || len(sr.Slots) == 0 {
+			continue
+		}
+		srs := sr.Slots[0]
+		signature := slotSignature(srs)
+		if wp.published[mode] == signature {
+			continue
+		}
+		wp.published[mode] = signature
+		if !initial {
+			wp.publish(srs)
+		}
+	}
+}
+
+func (wp *WebhookPublisher) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	wp.Check()
+	for {
+		select {
+		case <-wp.stop:
+			return
+		case <-ticker.C:
+			wp.Check()
+		}
+	}
+}
+
+func (wp *WebhookPublisher) Close() {
+	close(wp.stop)
+}
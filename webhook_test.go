@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookPublisher_Check(t *testing.T) {
+	var lock sync.Mutex
+	received := map[string][]string{}
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.URL.Path == "/discord" && failures > 0 {
+			failures -= 1
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received[r.URL.Path] = append(received[r.URL.Path], string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := newTestStore()
+	wp := NewWebhookPublisher(store, []WebhookTarget{
+		{URL: server.URL + "/discord", Format: "discord", Modes: []string{"X"}},
+		{URL: server.URL + "/json", Format: "json", Rules: []string{"CLAM"}},
+		{URL: server.URL + "/salmon", Format: "json", Modes: []string{"SALMON"}},
+	})
+	wp.RetryWait = time.Millisecond
+
+	// the first check must not publish anything
+	wp.Check()
+	if len(received) != 0 {
+		t.Fatalf("published on the first check: %v", received)
+	}
+
+	// move to the next rotation
+	store.now = testNow.Add(time.Hour)
+	wp.Check()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(received["/discord"]) != 1 {
+		t.Fatalf("discord webhook received %d payloads, want 1", len(received["/discord"]))
+	}
+	var discordPayload discordWebhookPayload
+	_ = json.Unmarshal([]byte(received["/discord"][0]), &discordPayload)
+	if len(discordPayload.Embeds) != 1 || discordPayload.Embeds[0].Author.Name != "Xマッチ" || discordPayload.Embeds[0].Title != "ガチアサリ" {
+		t.Errorf("discord payload = %v", received["/discord"][0])
+	}
+
+	if len(received["/json"]) != 1 {
+		t.Fatalf("json webhook received %d payloads, want 1", len(received["/json"]))
+	}
+	var jsonPayload jsonWebhookPayload
+	_ = json.Unmarshal([]byte(received["/json"][0]), &jsonPayload)
+	if jsonPayload.Slot.Mode != "X" || jsonPayload.Slot.Rule.Key != "CLAM" || jsonPayload.Slot.Stages[0] != "キンメダイ美術館" {
+		t.Errorf("json payload = %v", received["/json"][0])
+	}
+
+	// Salmon Run is not changed
+	if len(received["/salmon"]) != 0 {
+		t.Errorf("salmon webhook received %v", received["/salmon"])
+	}
+}